@@ -0,0 +1,148 @@
+package nuage
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/naivary/nuage/openapi"
+)
+
+// ErrAPIKeyUnknown is returned by an [APIKeyStore] if no principal owns the
+// API key.
+var ErrAPIKeyUnknown = errors.New("api key is unknown")
+
+// APIKeyStore looks up the principals owning API keys.
+type APIKeyStore interface {
+	// Lookup returns the principal owning key or [ErrAPIKeyUnknown].
+	Lookup(ctx context.Context, key string) (*Principal, error)
+}
+
+var _ APIKeyStore = (*MemoryAPIKeyStore)(nil)
+
+// MemoryAPIKeyStore is an [APIKeyStore] holding the API keys in memory. Keys
+// are compared in constant time to prevent timing attacks.
+type MemoryAPIKeyStore struct {
+	keys []memoryAPIKey
+}
+
+type memoryAPIKey struct {
+	// hash of the key. Comparing fixed size hashes is not leaking the
+	// length of the keys.
+	hash      [sha256.Size]byte
+	principal *Principal
+}
+
+// NewMemoryAPIKeyStore returns a store of the given API keys and the
+// principals owning them.
+func NewMemoryAPIKeyStore(keys map[string]*Principal) *MemoryAPIKeyStore {
+	s := &MemoryAPIKeyStore{
+		keys: make([]memoryAPIKey, 0, len(keys)),
+	}
+	for key, p := range keys {
+		s.keys = append(s.keys, memoryAPIKey{
+			hash:      sha256.Sum256([]byte(key)),
+			principal: p,
+		})
+	}
+	return s
+}
+
+func (s *MemoryAPIKeyStore) Lookup(ctx context.Context, key string) (*Principal, error) {
+	hash := sha256.Sum256([]byte(key))
+	var p *Principal
+	// all keys are compared to not leak the position of a match
+	for _, k := range s.keys {
+		if subtle.ConstantTimeCompare(hash[:], k.hash[:]) == 1 {
+			p = k.principal
+		}
+	}
+	if p == nil {
+		return nil, ErrAPIKeyUnknown
+	}
+	return p, nil
+}
+
+var _ Authenticator = (*APIKeyAuthenticator)(nil)
+
+// APIKeyAuthenticator authenticates requests using an API key in a header,
+// query parameter or cookie.
+type APIKeyAuthenticator struct {
+	in    openapi.ParamIn
+	name  string
+	store APIKeyStore
+}
+
+// NewAPIKeyAuthenticator returns an authenticator reading the API key from
+// the parameter name at the location in and looking it up in store.
+func NewAPIKeyAuthenticator(in openapi.ParamIn, name string, store APIKeyStore) (*APIKeyAuthenticator, error) {
+	switch in {
+	case openapi.ParamInHeader:
+		name = http.CanonicalHeaderKey(name)
+	case openapi.ParamInQuery, openapi.ParamInCookie:
+	default:
+		return nil, fmt.Errorf("api key: location is not supported: %s", in)
+	}
+	if name == "" {
+		return nil, errors.New("api key: name is empty")
+	}
+	if store == nil {
+		return nil, errors.New("api key: store is nil")
+	}
+	return &APIKeyAuthenticator{
+		in:    in,
+		name:  name,
+		store: store,
+	}, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(ctx *Context, r *http.Request) (*Principal, error) {
+	key := a.key(r)
+	if key == "" {
+		return nil, ErrUnauthorized.WithDetail(fmt.Sprintf("api key is missing in %s %q", a.in, a.name))
+	}
+	p, err := a.store.Lookup(ctx, key)
+	if errors.Is(err, ErrAPIKeyUnknown) {
+		return nil, ErrUnauthorized.WithDetail("api key is invalid")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (a *APIKeyAuthenticator) key(r *http.Request) string {
+	switch a.in {
+	case openapi.ParamInHeader:
+		return r.Header.Get(a.name)
+	case openapi.ParamInQuery:
+		return r.URL.Query().Get(a.name)
+	case openapi.ParamInCookie:
+		cookie, err := r.Cookie(a.name)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	default:
+		return ""
+	}
+}
+
+// RegisterAPIKey registers an apiKey security scheme under name which is
+// authenticating requests using the API key in the parameter paramName at the
+// location in. The keys are looked up in store.
+func (n *Nuage) RegisterAPIKey(name string, in openapi.ParamIn, paramName string, store APIKeyStore) error {
+	authn, err := NewAPIKeyAuthenticator(in, paramName, store)
+	if err != nil {
+		return err
+	}
+	scheme := &openapi.SecurityScheme{
+		Type: openapi.SecurityTypeAPIKey,
+		In:   in,
+		Name: authn.name,
+	}
+	return n.RegisterSecurityScheme(name, scheme, authn)
+}
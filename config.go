@@ -0,0 +1,466 @@
+package nuage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is the prefix of all environment variables used to configure
+// nuage.
+const EnvPrefix = "NUAGE_"
+
+// Config is the configuration of nuage. Following the 12 Factor App it is
+// populated from environment variables prefixed with [EnvPrefix] using
+// [ConfigFromEnv].
+type Config struct {
+	// Addr is the TCP address the server is listening on.
+	//
+	// Env: NUAGE_ADDR
+	Addr string
+
+	// ReadTimeout is the maximum duration for reading the entire request,
+	// including the body.
+	//
+	// Env: NUAGE_READ_TIMEOUT
+	ReadTimeout time.Duration
+
+	// ReadHeaderTimeout is the amount of time allowed to read the request
+	// headers.
+	//
+	// Env: NUAGE_READ_HEADER_TIMEOUT
+	ReadHeaderTimeout time.Duration
+
+	// WriteTimeout is the maximum duration before timing out writes of the
+	// response.
+	//
+	// Env: NUAGE_WRITE_TIMEOUT
+	WriteTimeout time.Duration
+
+	// IdleTimeout is the maximum amount of time to wait for the next request
+	// when keep-alives are enabled.
+	//
+	// Env: NUAGE_IDLE_TIMEOUT
+	IdleTimeout time.Duration
+
+	// ShutdownGracePeriod is the maximum duration in-flight requests are
+	// given to complete when the server is shutting down.
+	//
+	// Env: NUAGE_SHUTDOWN_GRACE_PERIOD
+	ShutdownGracePeriod time.Duration
+
+	// MaxBodySize is the maximum size of a request body in bytes. The value
+	// of the environment variable may use the units B, KiB, MiB and GiB e.g.
+	// 10MiB.
+	//
+	// Env: NUAGE_MAX_BODY_SIZE
+	MaxBodySize int64
+
+	// LogLevel is the minimum level of log records which are written.
+	//
+	// Env: NUAGE_LOG_LEVEL
+	LogLevel slog.Level
+
+	// OpenAPIPath is the path on which the OpenAPI document is served. The
+	// document is not served if it is empty.
+	//
+	// Env: NUAGE_OPENAPI_PATH
+	OpenAPIPath string
+
+	// OpenAPIYAML enables the YAML rendering of the OpenAPI document. It is
+	// served on OpenAPIPath with the extension ".json" replaced by ".yaml".
+	// OpenAPIPath has to end with ".json" if it is enabled.
+	//
+	// Env: NUAGE_OPENAPI_YAML
+	OpenAPIYAML bool
+
+	// CORSOrigins are the origins allowed to make cross-origin requests. The
+	// environment variable is a comma separated list. The wildcard "*" allows
+	// any origin.
+	//
+	// Env: NUAGE_CORS_ORIGINS
+	CORSOrigins []string
+
+	// CORSHeaders are the request headers allowed in cross-origin requests.
+	// The environment variable is a comma separated list.
+	//
+	// Env: NUAGE_CORS_HEADERS
+	CORSHeaders []string
+
+	// TLSCertFile is the file containing the PEM encoded certificate chain
+	// of the server used by [Nuage.ListenAndServeTLS].
+	//
+	// Env: NUAGE_TLS_CERT_FILE
+	TLSCertFile string
+
+	// TLSKeyFile is the file containing the PEM encoded private key matching
+	// the certificate in TLSCertFile.
+	//
+	// Env: NUAGE_TLS_KEY_FILE
+	TLSKeyFile string
+
+	// TLSClientCAFile is the file containing the PEM encoded certificates of
+	// the authorities client certificates are verified against. It is
+	// required for mutualTLS security schemes.
+	//
+	// Env: NUAGE_TLS_CLIENT_CA_FILE
+	TLSClientCAFile string
+
+	// TLSReloadInterval is the interval in which the files of the
+	// certificates are checked for changes and reloaded. Reloading is
+	// disabled if it is zero.
+	//
+	// Env: NUAGE_TLS_RELOAD_INTERVAL
+	TLSReloadInterval time.Duration
+}
+
+// DefaultConfig returns the configuration used for every environment variable
+// which is not set.
+func DefaultConfig() *Config {
+	return &Config{
+		Addr:                ":8080",
+		ReadTimeout:         30 * time.Second,
+		ReadHeaderTimeout:   5 * time.Second,
+		WriteTimeout:        30 * time.Second,
+		IdleTimeout:         2 * time.Minute,
+		ShutdownGracePeriod: 30 * time.Second,
+		MaxBodySize:         int64(1 * MiB / Byte),
+		LogLevel:            slog.LevelInfo,
+		OpenAPIPath:         "/openapi.json",
+		CORSHeaders:         []string{"Authorization", "Content-Type", HeaderRequestID},
+		TLSReloadInterval:   time.Minute,
+	}
+}
+
+// EnvError is the error of an environment variable which could not be parsed
+// or has an invalid value.
+type EnvError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e *EnvError) Error() string {
+	return fmt.Sprintf("%s=%q: %v", e.Name, e.Value, e.Err)
+}
+
+func (e *EnvError) Unwrap() error {
+	return e.Err
+}
+
+type envVar struct {
+	name string
+
+	// parse sets the field of the config using value
+	parse func(c *Config, value string) error
+
+	// validate reports whether the field of the config is valid
+	validate func(c *Config) error
+
+	// format returns the string representation of the field of the config
+	format func(c *Config) string
+}
+
+var envVars = []envVar{
+	{
+		name: "ADDR",
+		parse: func(c *Config, value string) error {
+			c.Addr = value
+			return nil
+		},
+		validate: func(c *Config) error {
+			if c.Addr == "" {
+				return errors.New("address is empty")
+			}
+			return nil
+		},
+		format: func(c *Config) string { return c.Addr },
+	},
+	durationEnvVar("READ_TIMEOUT", func(c *Config) *time.Duration { return &c.ReadTimeout }),
+	durationEnvVar("READ_HEADER_TIMEOUT", func(c *Config) *time.Duration { return &c.ReadHeaderTimeout }),
+	durationEnvVar("WRITE_TIMEOUT", func(c *Config) *time.Duration { return &c.WriteTimeout }),
+	durationEnvVar("IDLE_TIMEOUT", func(c *Config) *time.Duration { return &c.IdleTimeout }),
+	durationEnvVar("SHUTDOWN_GRACE_PERIOD", func(c *Config) *time.Duration { return &c.ShutdownGracePeriod }),
+	{
+		name: "MAX_BODY_SIZE",
+		parse: func(c *Config, value string) error {
+			size, err := parseSize(value)
+			if err != nil {
+				return err
+			}
+			c.MaxBodySize = size
+			return nil
+		},
+		validate: func(c *Config) error {
+			if c.MaxBodySize <= 0 {
+				return errors.New("size must be positive")
+			}
+			return nil
+		},
+		format: func(c *Config) string { return formatSize(c.MaxBodySize) },
+	},
+	{
+		name: "LOG_LEVEL",
+		parse: func(c *Config, value string) error {
+			return c.LogLevel.UnmarshalText([]byte(value))
+		},
+		format: func(c *Config) string { return c.LogLevel.String() },
+	},
+	{
+		name: "OPENAPI_PATH",
+		parse: func(c *Config, value string) error {
+			c.OpenAPIPath = value
+			return nil
+		},
+		validate: func(c *Config) error {
+			if c.OpenAPIPath != "" && !strings.HasPrefix(c.OpenAPIPath, "/") {
+				return errors.New("path must start with /")
+			}
+			return nil
+		},
+		format: func(c *Config) string { return c.OpenAPIPath },
+	},
+	{
+		name: "OPENAPI_YAML",
+		parse: func(c *Config, value string) error {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			c.OpenAPIYAML = enabled
+			return nil
+		},
+		validate: func(c *Config) error {
+			if c.OpenAPIYAML && c.OpenAPIPath == "" {
+				return errors.New("openapi path is empty")
+			}
+			// the path of the YAML document is derived from the openapi path
+			if c.OpenAPIYAML && !strings.HasSuffix(c.OpenAPIPath, ".json") {
+				return fmt.Errorf("openapi path has to end with .json: %s", c.OpenAPIPath)
+			}
+			return nil
+		},
+		format: func(c *Config) string { return strconv.FormatBool(c.OpenAPIYAML) },
+	},
+	{
+		name: "CORS_ORIGINS",
+		parse: func(c *Config, value string) error {
+			c.CORSOrigins = splitList(value)
+			return nil
+		},
+		validate: func(c *Config) error {
+			for _, origin := range c.CORSOrigins {
+				if origin == "*" {
+					continue
+				}
+				u, err := url.Parse(origin)
+				if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+					return fmt.Errorf("origin is invalid: %s", origin)
+				}
+			}
+			return nil
+		},
+		format: func(c *Config) string { return strings.Join(c.CORSOrigins, ",") },
+	},
+	{
+		name: "CORS_HEADERS",
+		parse: func(c *Config, value string) error {
+			c.CORSHeaders = splitList(value)
+			return nil
+		},
+		format: func(c *Config) string { return strings.Join(c.CORSHeaders, ",") },
+	},
+	{
+		name: "TLS_CERT_FILE",
+		parse: func(c *Config, value string) error {
+			c.TLSCertFile = value
+			return nil
+		},
+		validate: func(c *Config) error {
+			if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+				return errors.New("certificate and key file have to be set together")
+			}
+			return nil
+		},
+		format: func(c *Config) string { return c.TLSCertFile },
+	},
+	{
+		name: "TLS_KEY_FILE",
+		parse: func(c *Config, value string) error {
+			c.TLSKeyFile = value
+			return nil
+		},
+		format: func(c *Config) string { return c.TLSKeyFile },
+	},
+	{
+		name: "TLS_CLIENT_CA_FILE",
+		parse: func(c *Config, value string) error {
+			c.TLSClientCAFile = value
+			return nil
+		},
+		validate: func(c *Config) error {
+			if c.TLSClientCAFile != "" && c.TLSCertFile == "" {
+				return errors.New("client authorities require a server certificate")
+			}
+			return nil
+		},
+		format: func(c *Config) string { return c.TLSClientCAFile },
+	},
+	durationEnvVar("TLS_RELOAD_INTERVAL", func(c *Config) *time.Duration { return &c.TLSReloadInterval }),
+}
+
+func durationEnvVar(name string, field func(c *Config) *time.Duration) envVar {
+	return envVar{
+		name: name,
+		parse: func(c *Config, value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			*field(c) = d
+			return nil
+		},
+		validate: func(c *Config) error {
+			if *field(c) < 0 {
+				return errors.New("duration must not be negative")
+			}
+			return nil
+		},
+		format: func(c *Config) string { return field(c).String() },
+	}
+}
+
+// ConfigFromEnv returns the configuration defined by the environment
+// variables. Variables which are not set are defaulting to the values of
+// [DefaultConfig]. The returned error contains an [EnvError] for every
+// variable which could not be parsed and for every other variable which is
+// invalid.
+func ConfigFromEnv() (*Config, error) {
+	c := DefaultConfig()
+	var errs []error
+	invalid := make(map[string]bool)
+	for _, v := range envVars {
+		name := EnvPrefix + v.name
+		value, isSet := os.LookupEnv(name)
+		if !isSet {
+			continue
+		}
+		if err := v.parse(c, strings.TrimSpace(value)); err != nil {
+			errs = append(errs, &EnvError{Name: name, Value: value, Err: err})
+			invalid[v.name] = true
+		}
+	}
+	errs = append(errs, c.validate(invalid)...)
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate reports whether c is valid. The returned error contains an
+// [EnvError] for every invalid field of c.
+func (c *Config) Validate() error {
+	return errors.Join(c.validate(nil)...)
+}
+
+// validate returns the errors of the invalid fields of c. The fields of the
+// variables in skip are not validated.
+func (c *Config) validate(skip map[string]bool) []error {
+	var errs []error
+	for _, v := range envVars {
+		if v.validate == nil || skip[v.name] {
+			continue
+		}
+		if err := v.validate(c); err != nil {
+			errs = append(errs, &EnvError{Name: EnvPrefix + v.name, Value: v.format(c), Err: err})
+		}
+	}
+	return errs
+}
+
+// clone returns a deep copy of c.
+func (c *Config) clone() *Config {
+	cfg := *c
+	cfg.CORSOrigins = slices.Clone(c.CORSOrigins)
+	cfg.CORSHeaders = slices.Clone(c.CORSHeaders)
+	return &cfg
+}
+
+// WriteTo writes the effective configuration to w in the format of
+// environment variables, one per line.
+func (c *Config) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for _, v := range envVars {
+		written, err := fmt.Fprintf(w, "%s%s=%s\n", EnvPrefix, v.name, v.format(c))
+		n += int64(written)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	// ordered by length of the suffix to match the longest suffix first
+	{"GiB", int64(GiB / Byte)},
+	{"MiB", int64(MiB / Byte)},
+	{"KiB", int64(KiB / Byte)},
+	{"B", 1},
+}
+
+// parseSize parses a size in bytes with an optional unit e.g. 10MiB.
+func parseSize(value string) (int64, error) {
+	unit := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(value, u.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, u.suffix))
+			unit = u.bytes
+			break
+		}
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if size > math.MaxInt64/unit {
+		return 0, errors.New("size is too large")
+	}
+	return size * unit, nil
+}
+
+// formatSize formats size using the largest unit which is representing size
+// without loss.
+func formatSize(size int64) string {
+	for _, u := range sizeUnits {
+		if size != 0 && size%u.bytes == 0 {
+			return strconv.FormatInt(size/u.bytes, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(size, 10) + "B"
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	items := strings.Split(value, ",")
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,97 @@
+package nuage_test
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/naivary/nuage"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("NUAGE_ADDR", ":9090")
+	t.Setenv("NUAGE_READ_TIMEOUT", "10s")
+	t.Setenv("NUAGE_MAX_BODY_SIZE", "2MiB")
+	t.Setenv("NUAGE_LOG_LEVEL", "debug")
+	t.Setenv("NUAGE_CORS_ORIGINS", "https://example.com, http://localhost:3000")
+	cfg, err := nuage.ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("config from env: %v", err)
+	}
+	if cfg.Addr != ":9090" {
+		t.Errorf("addr: got %s", cfg.Addr)
+	}
+	if cfg.ReadTimeout != 10*time.Second {
+		t.Errorf("read timeout: got %s", cfg.ReadTimeout)
+	}
+	if cfg.MaxBodySize != 2*1024*1024 {
+		t.Errorf("max body size: got %d", cfg.MaxBodySize)
+	}
+	if cfg.LogLevel != slog.LevelDebug {
+		t.Errorf("log level: got %s", cfg.LogLevel)
+	}
+	if len(cfg.CORSOrigins) != 2 {
+		t.Errorf("cors origins: got %v", cfg.CORSOrigins)
+	}
+	// unset variables are defaulted
+	if cfg.OpenAPIPath != nuage.DefaultConfig().OpenAPIPath {
+		t.Errorf("openapi path: got %s", cfg.OpenAPIPath)
+	}
+
+	var b strings.Builder
+	if _, err := cfg.WriteTo(&b); err != nil {
+		t.Fatalf("write to: %v", err)
+	}
+	for _, line := range []string{"NUAGE_ADDR=:9090", "NUAGE_MAX_BODY_SIZE=2MiB", "NUAGE_LOG_LEVEL=DEBUG"} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("effective config is missing %q:\n%s", line, b.String())
+		}
+	}
+}
+
+func TestConfigFromEnv_Invalid(t *testing.T) {
+	t.Setenv("NUAGE_READ_TIMEOUT", "ten seconds")
+	t.Setenv("NUAGE_MAX_BODY_SIZE", "1TB")
+	t.Setenv("NUAGE_LOG_LEVEL", "verbose")
+	// parsed but invalid values are reported together with the parse errors
+	t.Setenv("NUAGE_WRITE_TIMEOUT", "-1s")
+	t.Setenv("NUAGE_OPENAPI_PATH", "openapi.json")
+	_, err := nuage.ConfigFromEnv()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	names := []string{
+		"NUAGE_READ_TIMEOUT", "NUAGE_MAX_BODY_SIZE", "NUAGE_LOG_LEVEL",
+		"NUAGE_WRITE_TIMEOUT", "NUAGE_OPENAPI_PATH",
+	}
+	for _, name := range names {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error is not reporting %s: %v", name, err)
+		}
+	}
+	var envErr *nuage.EnvError
+	if !errors.As(err, &envErr) {
+		t.Errorf("error is not an EnvError: %v", err)
+	}
+}
+
+func TestNuage_Config(t *testing.T) {
+	cfg := nuage.DefaultConfig()
+	cfg.CORSOrigins = []string{"https://example.com"}
+	n, err := nuage.NewWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	cfg.CORSOrigins[0] = "https://evil.com"
+	got := n.Config()
+	got.CORSOrigins[0] = "https://evil.com"
+	got.CORSHeaders[0] = "X-Evil"
+	if origins := n.Config().CORSOrigins; origins[0] != "https://example.com" {
+		t.Errorf("cors origins are mutable: %v", origins)
+	}
+	if headers := n.Config().CORSHeaders; headers[0] == "X-Evil" {
+		t.Errorf("cors headers are mutable: %v", headers)
+	}
+}
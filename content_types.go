@@ -35,4 +35,10 @@ const (
 	// machine-readable error information in a consistent format, which can
 	// be programmatically processed or displayed to users.
 	ContentTypeHTTPError = "application/problem+json"
+
+	// ContentTypeYAML represents the YAML media type as defined in RFC 9512.
+	//
+	// This content type is used for the YAML rendering of the OpenAPI
+	// document.
+	ContentTypeYAML = "application/yaml"
 )
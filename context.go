@@ -2,19 +2,169 @@ package nuage
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
 	"time"
+
+	"github.com/naivary/nuage/openapi"
 )
 
+// HeaderRequestID is the header used to propagate the id of a request. A valid
+// id provided by the client is reused otherwise a new one is generated.
+const HeaderRequestID = "X-Request-Id"
+
+// maxRequestIDLen is the maximum length of a request id provided by a client.
+const maxRequestIDLen = 128
+
 var _ context.Context = (*Context)(nil)
 
+type ctxKey struct{}
+
+// Context is the context of a single request shared by middlewares and
+// handlers. It is derived from the context of the incoming request and carries
+// the request metadata known to the framework.
 type Context struct {
 	ctx context.Context
+
+	r         *http.Request
+	op        *openapi.Operation
+	requestID string
+	logger    *slog.Logger
+	principal *Principal
+}
+
+// Principal is the authenticated entity on whose behalf a request is made.
+type Principal struct {
+	// Subject uniquely identifies the principal in the context of the
+	// security scheme it was authenticated with.
+	Subject string
+
+	// Scheme is the name of the security scheme which authenticated the
+	// principal.
+	Scheme string
+
+	// Scopes granted to the principal.
+	Scopes []string
+
+	// Claims of the verified token if the principal was authenticated using
+	// a bearer token.
+	Claims map[string]any
+
+	// Certificate is the verified client certificate if the principal was
+	// authenticated using mutual TLS.
+	Certificate *x509.Certificate
 }
 
-func NewCtx() *Context {
-	return &Context{
-		ctx: context.Background(),
+// NewCtx returns the Context of r. If r is already carrying a Context it is
+// returned otherwise a new one derived from the context of r is created.
+func NewCtx(r *http.Request) *Context {
+	if c, isCtx := r.Context().Value(ctxKey{}).(*Context); isCtx {
+		return c
 	}
+	return newCtx(r, slog.Default(), nil)
+}
+
+// newCtx creates a new Context for r serving op. The logger of the context is
+// a child of logger annotated with the request metadata.
+func newCtx(r *http.Request, logger *slog.Logger, op *openapi.Operation) *Context {
+	c := &Context{
+		ctx:       r.Context(),
+		op:        op,
+		requestID: requestID(r),
+	}
+	attrs := []any{
+		slog.String("request_id", c.requestID),
+		slog.String("method", r.Method),
+	}
+	if op != nil {
+		attrs = append(attrs,
+			slog.String("operation_id", op.OperationID),
+			slog.String("pattern", op.Pattern),
+		)
+	}
+	c.logger = logger.With(attrs...)
+	c.r = r.WithContext(c)
+	return c
+}
+
+// withRequest returns the Context of r which has passed through the
+// middlewares of c. If a middleware replaced the context of the request the
+// returned Context is derived from it, keeping the request metadata of c, to
+// make the values added by the middleware available.
+func (c *Context) withRequest(r *http.Request) *Context {
+	if r.Context() == context.Context(c) {
+		return c
+	}
+	derived := *c
+	derived.ctx = r.Context()
+	derived.r = r.WithContext(&derived)
+	return &derived
+}
+
+// requestID returns the request id provided by the client in the header
+// [HeaderRequestID] or generates a new one if it is missing or invalid.
+func requestID(r *http.Request) string {
+	id := r.Header.Get(HeaderRequestID)
+	if isValidRequestID(id) {
+		return id
+	}
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func isValidRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := range len(id) {
+		// only visible ASCII characters are allowed
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Request returns the request of the context. The context of the returned
+// request is c.
+func (c *Context) Request() *http.Request {
+	return c.r
+}
+
+// Operation returns the operation which is served by the request. It is nil
+// if the handler is used without being registered using [Handle].
+func (c *Context) Operation() *openapi.Operation {
+	return c.op
+}
+
+// Pattern returns the pattern which matched the request.
+func (c *Context) Pattern() string {
+	return c.r.Pattern
+}
+
+// RequestID returns the id of the request.
+func (c *Context) RequestID() string {
+	return c.requestID
+}
+
+// Logger returns the logger of the request.
+func (c *Context) Logger() *slog.Logger {
+	return c.logger
+}
+
+// Principal returns the authenticated principal of the request or nil if the
+// request is unauthenticated.
+func (c *Context) Principal() *Principal {
+	return c.principal
+}
+
+// SetPrincipal sets the authenticated principal of the request.
+func (c *Context) SetPrincipal(p *Principal) {
+	c.principal = p
 }
 
 func (c *Context) Deadline() (time.Time, bool) {
@@ -30,5 +180,8 @@ func (c *Context) Err() error {
 }
 
 func (c *Context) Value(key any) any {
+	if _, isCtxKey := key.(ctxKey); isCtxKey {
+		return c
+	}
 	return c.ctx.Value(key)
 }
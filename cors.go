@@ -0,0 +1,86 @@
+package nuage
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// cors handles cross-origin requests for the configured origins as defined by
+// the Fetch standard. It reports whether the request was a preflight request
+// which has been answered.
+func (n *Nuage) cors(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(n.cfg.CORSOrigins) == 0 {
+		return false
+	}
+	w.Header().Add("Vary", "Origin")
+	if !n.isAllowedOrigin(origin) {
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Expose-Headers", HeaderRequestID)
+	method := r.Header.Get("Access-Control-Request-Method")
+	if r.Method != http.MethodOptions || method == "" {
+		return false
+	}
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+	// the requested method and headers are not reflected. Only the methods
+	// of the route and the configured headers are allowed.
+	methods := n.routeMethods(r)
+	if slices.Contains(methods, method) {
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	}
+	if headers := n.allowedHeaders(r.Header.Get("Access-Control-Request-Headers")); len(headers) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// corsMethods are the methods for which the routes are looked up when
+// answering a preflight request.
+var corsMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// routeMethods returns the methods for which an operation is registered on
+// the path of r.
+func (n *Nuage) routeMethods(r *http.Request) []string {
+	methods := make([]string, 0, len(corsMethods))
+	for _, method := range corsMethods {
+		probe := *r
+		probe.Method = method
+		if _, pattern := n.mux.Handler(&probe); pattern != "" {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// allowedHeaders returns the headers of the comma separated list requested
+// which are allowed by the configuration.
+func (n *Nuage) allowedHeaders(requested string) []string {
+	var headers []string
+	for _, header := range splitList(requested) {
+		isAllowed := slices.ContainsFunc(n.cfg.CORSHeaders, func(allowed string) bool {
+			return strings.EqualFold(allowed, header)
+		})
+		if isAllowed {
+			headers = append(headers, header)
+		}
+	}
+	return headers
+}
+
+func (n *Nuage) isAllowedOrigin(origin string) bool {
+	return slices.ContainsFunc(n.cfg.CORSOrigins, func(allowed string) bool {
+		return allowed == "*" || strings.EqualFold(allowed, origin)
+	})
+}
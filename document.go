@@ -0,0 +1,128 @@
+package nuage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/naivary/nuage/internal/yamlutil"
+	"github.com/naivary/nuage/openapi"
+)
+
+// renderedDocument contains the representations of the OpenAPI document
+// served by nuage.
+type renderedDocument struct {
+	json *representation
+	yaml *representation
+}
+
+// representation is a rendered document served with a strong entity tag.
+type representation struct {
+	contentType string
+	body        []byte
+	etag        string
+}
+
+func newRepresentation(contentType string, body []byte) *representation {
+	sum := sha256.Sum256(body)
+	return &representation{
+		contentType: contentType,
+		body:        body,
+		etag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}
+}
+
+// serveDocument registers the handlers serving the OpenAPI document on the
+// configured paths. Nothing is registered if no path is configured.
+func (n *Nuage) serveDocument() error {
+	if n.cfg.OpenAPIPath == "" {
+		return nil
+	}
+	err := n.documentHandler(n.cfg.OpenAPIPath, func(doc *renderedDocument) *representation {
+		return doc.json
+	})
+	if err != nil || !n.cfg.OpenAPIYAML {
+		return err
+	}
+	return n.documentHandler(yamlPath(n.cfg.OpenAPIPath), func(doc *renderedDocument) *representation {
+		return doc.yaml
+	})
+}
+
+// documentHandler registers the handler serving the representation of the
+// OpenAPI document selected by rep on path. The document is rendered once on
+// the first request. Like operations the requests are passed through the
+// middlewares and written to the access log but the document is not
+// describing itself.
+func (n *Nuage) documentHandler(path string, rep func(doc *renderedDocument) *representation) error {
+	op := &openapi.Operation{Pattern: http.MethodGet + " " + path}
+	serve := func(ctx *Context, w http.ResponseWriter, r *http.Request) error {
+		doc, err := n.renderDocument()
+		if err != nil {
+			return err
+		}
+		rep(doc).ServeHTTP(w, r)
+		return nil
+	}
+	return handle(n.mux, op.Pattern, n.withContext(op, n.endpoint(serve, nil)))
+}
+
+// render renders the OpenAPI document of n in all enabled representations.
+func (n *Nuage) render() (*renderedDocument, error) {
+	data, err := json.Marshal(n.doc)
+	if err != nil {
+		return nil, err
+	}
+	doc := &renderedDocument{json: newRepresentation(ContentTypeJSON, data)}
+	if !n.cfg.OpenAPIYAML {
+		return doc, nil
+	}
+	data, err = yamlutil.FromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	doc.yaml = newRepresentation(ContentTypeYAML, data)
+	return doc, nil
+}
+
+func (rep *representation) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("ETag", rep.etag)
+	// clients have to revalidate the document because it changes with every
+	// deployment of the service.
+	w.Header().Set("Cache-Control", "no-cache")
+	if matchETag(r.Header.Values("If-None-Match"), rep.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", rep.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(rep.body)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(rep.body)
+}
+
+// matchETag reports whether one of the entity tags listed in the values of an
+// If-None-Match header matches etag using the weak comparison of RFC 9110.
+func matchETag(values []string, etag string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) == "*" {
+			return true
+		}
+		for candidate := range strings.SplitSeq(value, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// yamlPath returns the path of the YAML rendering of the OpenAPI document
+// served on path. The extension ".json" of path is replaced by ".yaml" which
+// is ensured by [Config.Validate].
+func yamlPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + ".yaml"
+}
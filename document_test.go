@@ -0,0 +1,117 @@
+package nuage_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/naivary/nuage"
+	"github.com/naivary/nuage/openapi"
+)
+
+func TestDocument(t *testing.T) {
+	cfg := nuage.DefaultConfig()
+	cfg.OpenAPIYAML = true
+	n, err := nuage.NewWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	n.Document().Info.Title = "users"
+	err = nuage.Handle(n, noop, &openapi.Operation{Pattern: "GET /users", OperationID: "listUsers"})
+	if err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d", rec.Code)
+	}
+	var doc openapi.OpenAPI
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if doc.Version != openapi.OpenAPIVersion || doc.JSONSchemaDialect != openapi.JSONSchemaDialect {
+		t.Errorf("version and dialect not set: %q %q", doc.Version, doc.JSONSchemaDialect)
+	}
+	if doc.Paths["/users"] == nil || doc.Paths["/users"].Get == nil {
+		t.Errorf("operation not documented")
+	}
+	etag := rec.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("strong etag expected: %q", etag)
+	}
+
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		rec := httptest.NewRecorder()
+		n.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: got %d", ifNoneMatch, rec.Code)
+		}
+	}
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	req.Header.Set("If-None-Match", `"other"`)
+	rec = httptest.NewRecorder()
+	n.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("mismatching etag: got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	n.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("yaml status: got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != nuage.ContentTypeYAML {
+		t.Errorf("yaml content type: got %s", ct)
+	}
+	if !strings.HasPrefix(rec.Body.String(), `openapi: "3.2.0"`) {
+		t.Errorf("unexpected yaml document:\n%s", rec.Body)
+	}
+	if rec.Header().Get("ETag") == etag {
+		t.Errorf("representations must not share an etag")
+	}
+}
+
+func TestDocument_Middleware(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	var patterns []string
+	n.Use(func(next nuage.NextFunc) nuage.NextFunc {
+		return func(ctx *nuage.Context, w http.ResponseWriter, r *http.Request) error {
+			patterns = append(patterns, ctx.Operation().Pattern)
+			return next(ctx, w, r)
+		}
+	})
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d", rec.Code)
+	}
+	if rec.Header().Get(nuage.HeaderRequestID) == "" {
+		t.Errorf("request id is not set")
+	}
+	if len(patterns) != 1 || patterns[0] != "GET /openapi.json" {
+		t.Errorf("middleware not applied: %v", patterns)
+	}
+	if _, isDocumented := n.Document().Paths["/openapi.json"]; isDocumented {
+		t.Errorf("document is describing itself")
+	}
+}
+
+func TestDocument_InvalidYAMLPath(t *testing.T) {
+	for _, path := range []string{"/openapi.yaml", "/openapi"} {
+		cfg := nuage.DefaultConfig()
+		cfg.OpenAPIPath = path
+		cfg.OpenAPIYAML = true
+		if _, err := nuage.NewWithConfig(cfg); err == nil {
+			t.Errorf("%s: expected an error", path)
+		}
+	}
+}
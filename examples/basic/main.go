@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -9,7 +10,8 @@ import (
 
 func main() {
 	if err := run(); err != nil {
-		fmt.Fprintf(os.Stdout, "err: %v", err)
+		fmt.Fprintf(os.Stderr, "err: %v\n", err)
+		os.Exit(1)
 	}
 }
 
@@ -18,6 +20,5 @@ func run() error {
 	if err != nil {
 		return err
 	}
-	_ = api
-	return nil
+	return api.ListenAndServe(context.Background())
 }
@@ -4,10 +4,8 @@ go 1.25.5
 
 require (
 	github.com/google/jsonschema-go v0.4.2
+	golang.org/x/sync v0.19.0
 	golang.org/x/tools v0.41.0
 )
 
-require (
-	golang.org/x/mod v0.32.0 // indirect
-	golang.org/x/sync v0.19.0 // indirect
-)
+require golang.org/x/mod v0.32.0 // indirect
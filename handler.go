@@ -1,9 +1,21 @@
 package nuage
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
 	"net/http"
+	"reflect"
+	"slices"
+	"time"
 
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/naivary/nuage/internal/openapiutil"
+	"github.com/naivary/nuage/jsoncodec"
 	"github.com/naivary/nuage/openapi"
 )
 
@@ -11,6 +23,16 @@ type Decoder interface {
 	Decode(r *http.Request) error
 }
 
+// Bodier is implemented by request models expecting a request body. Body
+// returns a pointer to the part of the request model into which the request
+// body is decoded.
+//
+// Alternatively a field of the request model can be tagged with `body`. Its
+// value is decoded by the generated Decode method without reflection.
+type Bodier interface {
+	Body() any
+}
+
 var _ Decoder = (*request)(nil)
 
 // used to satisfy Decoder and to be used for compile-time
@@ -19,7 +41,7 @@ type request struct{}
 
 func (r request) Decode(req *http.Request) error { return nil }
 
-var _ http.Handler = (HandlerFuncErr[request, struct{}])(nil)
+var _ http.Handler = (HandlerFuncErr[request, NoContent])(nil)
 
 // HandlerFuncErr is the primary request handler function signature used by the
 // framework to implement REST API endpoints.
@@ -29,24 +51,305 @@ func (hl HandlerFuncErr[RequestModel, ResponseModel]) ServeHTTP(
 	w http.ResponseWriter,
 	r *http.Request,
 ) {
-	var req RequestModel
-	ctx := NewCtx()
-	res, err := hl(ctx, req)
-	if err != nil {
-		// handle error
-		return
+	ctx := NewCtx(r)
+	if err := hl.serve(nil)(ctx, w, ctx.Request()); err != nil {
+		writeError(w, ctx.Request(), err)
 	}
-	err = json.NewEncoder(w).Encode(&res)
-	if err != nil {
-		// handle err
-		return
+}
+
+// serve returns the innermost NextFunc of every middleware chain. It decodes
+// the request model after validating the request body using v, calls hl and
+// writes the response model.
+func (hl HandlerFuncErr[RequestModel, ResponseModel]) serve(v *validator) NextFunc {
+	return func(ctx *Context, w http.ResponseWriter, r *http.Request) error {
+		ctx = ctx.withRequest(r)
+		r = ctx.Request()
+		op := ctx.Operation()
+		req := newModel[RequestModel]()
+		if err := decodeRequest(r, op, v, req); err != nil {
+			return err
+		}
+		res, err := hl(ctx, req)
+		if err != nil {
+			return err
+		}
+		return writeResponse(w, r, op, res)
 	}
 }
 
+// Handle registers hl for the pattern of op on n and adds op to the OpenAPI
+// document of n. An error is returned if an operation with the same method and
+// path or the same operation id is already registered.
+//
+// The request is passed through the global middlewares, the middlewares of
+// the group n and finally mws before hl is called. See [Nuage.Use] for the
+// ordering of middlewares.
 func Handle[RequestModel Decoder, ResponseModel any](
 	n *Nuage,
-	hl HandlerFuncErr[RequestModel, RequestModel],
+	hl HandlerFuncErr[RequestModel, ResponseModel],
 	op *openapi.Operation,
+	mws ...Middleware,
 ) error {
+	if hl == nil {
+		return errors.New("handler is nil")
+	}
+	if op == nil {
+		return errors.New("operation is nil")
+	}
+	if err := n.validateSecurity(op); err != nil {
+		return err
+	}
+	if err := documentParameters(op, reflect.TypeFor[RequestModel]()); err != nil {
+		return err
+	}
+	bodyType := requestBodyType(newModel[RequestModel]())
+	if bodyType != nil && op.RequestContentType == "" {
+		op.RequestContentType = ContentTypeJSON
+		switch {
+		case isMergePatch(bodyType):
+			op.RequestContentType = ContentTypeMergePatch
+		case isJSONPatch(bodyType):
+			op.RequestContentType = ContentTypeJSONPatch
+		}
+	}
+	schemas := n.stageSchemas()
+	var v *validator
+	if op.RequestContentType != "" {
+		if !isRequestContentTypeSupported(op.RequestContentType) {
+			return fmt.Errorf("request content type is not supported: %s", op.RequestContentType)
+		}
+		var schema *jsonschema.Schema
+		var err error
+		switch {
+		case op.RequestContentType == ContentTypeJSONPatch:
+			schema = schemas.jsonPatchSchemaRef()
+		case bodyType == nil:
+		case op.RequestContentType == ContentTypeMergePatch:
+			schema, err = schemas.patchSchemaRef(bodyType)
+		default:
+			schema, err = schemas.schemaRef(bodyType)
+		}
+		if err != nil {
+			return err
+		}
+		v, err = newValidator(schema, schemas.schemas, isStrictBody(reflect.TypeFor[RequestModel]()))
+		if err != nil {
+			return err
+		}
+		op.RequestBody = &openapi.RequestBody{
+			Description: op.RequestDesc,
+			Required:    isRequestBodyRequired(op),
+			Content: map[string]*openapi.MediaType{
+				op.RequestContentType: {Schema: schema},
+			},
+		}
+	}
+	status := responseStatusCode[ResponseModel](op)
+	if !isNoContent[ResponseModel]() && !bodyAllowedForStatus(status) {
+		return fmt.Errorf("response status code %d does not allow a body. Use NoContent as response model", status)
+	}
+	var schema *jsonschema.Schema
+	if !isNoContent[ResponseModel]() {
+		var err error
+		schema, err = schemas.schemaRef(reflect.TypeFor[ResponseModel]())
+		if err != nil {
+			return err
+		}
+	}
+	documentResponse[ResponseModel](op, schema)
+	if err := n.register(op, n.withContext(op, n.endpoint(hl.serve(v), mws))); err != nil {
+		return err
+	}
+	n.commitSchemas(schemas)
+	return nil
+}
+
+// withContext creates the Context of every request served by next and makes
+// op available through it. The size of the request body is limited to the
+// configured maximum and every served request is written to the access log.
+func (n *Nuage) withContext(op *openapi.Operation, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, n.cfg.MaxBodySize)
+		}
+		ctx := newCtx(r, n.logger, op)
+		rw := &responseWriter{ResponseWriter: w}
+		rw.Header().Set(HeaderRequestID, ctx.RequestID())
+		next.ServeHTTP(rw, ctx.Request())
+		ctx.Logger().LogAttrs(ctx, slog.LevelInfo, "request served",
+			slog.Int("status", rw.Status()),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int64("size", rw.size),
+		)
+	})
+}
+
+// newModel returns a usable value of T. If T is a pointer a pointer to the
+// zero value of the element type is returned instead of nil.
+func newModel[T any]() T {
+	var model T
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() == reflect.Pointer {
+		model = reflect.New(typ.Elem()).Interface().(T)
+	}
+	return model
+}
+
+func isRequestContentTypeSupported(contentType string) bool {
+	switch contentType {
+	case ContentTypeJSON, ContentTypeMergePatch, ContentTypeJSONPatch:
+		return true
+	default:
+		return false
+	}
+}
+
+// requestBodyType returns the type of the request body of the request model
+// or nil if the request model is not expecting a body. The body is either
+// provided by [Bodier] or the field of the request model tagged with `body`
+// which is decoded by the generated decoder.
+func requestBodyType(model any) reflect.Type {
+	if bodier, isBodier := model.(Bodier); isBodier {
+		return reflect.TypeOf(bodier.Body())
+	}
+	if field, hasBody := openapiutil.BodyField(reflect.TypeOf(model)); hasBody {
+		return field.Type
+	}
+	return nil
+}
+
+// isStrictBody reports whether the body field of the request model is
+// rejecting unknown object members. Bodies provided by [Bodier] are decoded
+// by encoding/json which is ignoring unknown members.
+func isStrictBody(model reflect.Type) bool {
+	field, hasBody := openapiutil.BodyField(model)
+	if !hasBody {
+		return false
+	}
+	opts, err := openapiutil.ParseBodyOpts(field.Tag)
+	return err == nil && opts.Strict
+}
+
+// documentParameters adds the parameters of the request model to op including
+// the constraints defined in the struct tags of its fields. Parameters already
+// defined by op are left untouched.
+func documentParameters(op *openapi.Operation, model reflect.Type) error {
+	for model.Kind() == reflect.Pointer {
+		model = model.Elem()
+	}
+	if model.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := range model.NumField() {
+		field := model.Field(i)
+		opts, err := openapiutil.ParseParamOpts(field.Tag)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+		if opts == nil {
+			continue
+		}
+		isDefined := slices.ContainsFunc(op.Parameters, func(p *openapi.Parameter) bool {
+			return p.Name == opts.Name && p.ParamIn == opts.In
+		})
+		if isDefined {
+			continue
+		}
+		param, err := openapiutil.NewParam(opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+		param.Schema, err = openapiutil.ParamSchema(field.Type, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+	return nil
+}
+
+// isRequestBodyRequired reports whether op requires a request body. If not
+// explicitly defined by the operation a request body is required as soon as
+// a request content type is defined.
+func isRequestBodyRequired(op *openapi.Operation) bool {
+	if op.IsRequestBodyRequired != nil {
+		return *op.IsRequestBodyRequired
+	}
+	return op.RequestContentType != ""
+}
+
+// decodeRequest decodes the parameters and the body of r into req. The body
+// is validated using v before it is decoded.
+func decodeRequest(r *http.Request, op *openapi.Operation, v *validator, req Decoder) error {
+	body, err := readBody(r, op)
+	if err != nil {
+		return err
+	}
+	if len(body) > 0 {
+		if err := v.validate(body); err != nil {
+			return err
+		}
+	}
+	if err := req.Decode(r); err != nil {
+		if asHTTPError(err) != nil {
+			return err
+		}
+		var decodeErr *jsoncodec.DecodeError
+		if errors.As(err, &decodeErr) {
+			return ErrRequestBodyInvalid.WithDetail(decodeErr.Error()).WithExtension("pointer", decodeErr.Pointer)
+		}
+		return ErrParamInvalid.WithDetail(err.Error())
+	}
+	bodier, isBodier := req.(Bodier)
+	if !isBodier || len(body) == 0 {
+		return nil
+	}
+	return decodeJSON(body, bodier.Body())
+}
+
+// readBody reads the request body of r if op is expecting one. The body of r
+// is replaced by the read content allowing generated decoders to consume it.
+func readBody(r *http.Request, op *openapi.Operation) ([]byte, error) {
+	if op == nil || op.RequestContentType == "" || r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, ErrRequestBodyTooLarge.WithDetail(
+				fmt.Sprintf("request body must not exceed %d bytes", maxBytesErr.Limit),
+			)
+		}
+		return nil, ErrRequestBodyInvalid.WithDetail(err.Error())
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) == 0 {
+		if isRequestBodyRequired(op) {
+			return nil, ErrRequestBodyMissing
+		}
+		return nil, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != op.RequestContentType {
+		return nil, ErrUnsupportedMediaType.WithDetail(
+			fmt.Sprintf("content type must be %s", op.RequestContentType),
+		)
+	}
+	return body, nil
+}
+
+// decodeJSON decodes the JSON document data into v. Any data following the
+// document is treated as an error.
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(v); err != nil {
+		return ErrRequestBodyInvalid.WithDetail(err.Error())
+	}
+	if err := dec.Decode(&json.RawMessage{}); err != io.EOF {
+		return ErrRequestBodyInvalid.WithDetail("unexpected data after JSON document")
+	}
 	return nil
 }
@@ -0,0 +1,644 @@
+package nuage_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/naivary/nuage"
+	"github.com/naivary/nuage/jsoncodec"
+	"github.com/naivary/nuage/jsonpatch"
+	"github.com/naivary/nuage/openapi"
+)
+
+type user struct {
+	Name string `json:"name"`
+}
+
+type createUserRequest struct {
+	Tenant string
+	User   user
+}
+
+func (r *createUserRequest) Decode(req *http.Request) error {
+	r.Tenant = req.PathValue("tenant")
+	return nil
+}
+
+func (r *createUserRequest) Body() any { return &r.User }
+
+func TestHandlerFuncErr_Decode(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	var got *createUserRequest
+	hl := func(ctx *nuage.Context, r *createUserRequest) (struct{}, error) {
+		got = r
+		return struct{}{}, nil
+	}
+	op := &openapi.Operation{
+		Pattern:     "POST /tenants/{tenant}/users",
+		OperationID: "createUser",
+	}
+	if err := nuage.Handle(n, hl, op); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/tenants/acme/users", strings.NewReader(`{"name":"jane"}`))
+	req.Header.Set("Content-Type", nuage.ContentTypeJSON)
+	n.ServeHTTP(httptest.NewRecorder(), req)
+	if got == nil {
+		t.Fatalf("handler was not called")
+	}
+	if got.Tenant != "acme" || got.User.Name != "jane" {
+		t.Errorf("unexpected request model: %+v", got)
+	}
+}
+
+func TestHandlerFuncErr_Error(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		typ    string
+	}{
+		{
+			name:   "http error",
+			err:    nuage.ErrUnauthorized,
+			status: http.StatusUnauthorized,
+			typ:    nuage.ErrUnauthorized.Type,
+		},
+		{
+			name:   "wrapped http error",
+			err:    fmt.Errorf("lookup: %w", nuage.ErrParamInvalid.WithDetail("id is not valid")),
+			status: http.StatusBadRequest,
+			typ:    nuage.ErrParamInvalid.Type,
+		},
+		{
+			name:   "plain error",
+			err:    errors.New("database is down"),
+			status: http.StatusInternalServerError,
+			typ:    nuage.ErrInternal.Type,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hl := nuage.HandlerFuncErr[*emptyRequest, struct{}](func(ctx *nuage.Context, r *emptyRequest) (struct{}, error) {
+				return struct{}{}, tc.err
+			})
+			rec := httptest.NewRecorder()
+			hl.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tc.status {
+				t.Errorf("status: got %d want %d", rec.Code, tc.status)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != nuage.ContentTypeHTTPError {
+				t.Errorf("content type: got %s", ct)
+			}
+			var problem map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if problem["type"] != tc.typ {
+				t.Errorf("type: got %v want %s", problem["type"], tc.typ)
+			}
+			if strings.Contains(rec.Body.String(), "database") {
+				t.Errorf("internal error is leaked: %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandlerFuncErr_Response(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	created := func(ctx *nuage.Context, r *emptyRequest) (user, error) {
+		return user{Name: "jane"}, nil
+	}
+	deleted := func(ctx *nuage.Context, r *emptyRequest) (nuage.NoContent, error) {
+		return nuage.NoContent{}, nil
+	}
+	err = nuage.Handle(n, created, &openapi.Operation{Pattern: "POST /users", ResponseStatusCode: http.StatusCreated})
+	if err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	err = nuage.Handle(n, deleted, &openapi.Operation{Pattern: "DELETE /users/{id}"})
+	if err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", nil))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status: got %d want %d", rec.Code, http.StatusCreated)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != nuage.ContentTypeJSON {
+		t.Errorf("content type: got %s", ct)
+	}
+	if cl := rec.Header().Get("Content-Length"); cl != strconv.Itoa(rec.Body.Len()) {
+		t.Errorf("content length: got %s want %d", cl, rec.Body.Len())
+	}
+
+	rec = httptest.NewRecorder()
+	n.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/users/1", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status: got %d want %d", rec.Code, http.StatusNoContent)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("body is not empty: %s", rec.Body.String())
+	}
+}
+
+type encodedResponse struct{}
+
+func (r *encodedResponse) EncodeJSON(w io.Writer) error {
+	_, err := io.WriteString(w, `{"encoder":"generated"}`+"\n")
+	return err
+}
+
+func TestHandlerFuncErr_JSONEncoder(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	hl := func(ctx *nuage.Context, r *emptyRequest) (encodedResponse, error) {
+		return encodedResponse{}, nil
+	}
+	if err := nuage.Handle(n, hl, &openapi.Operation{Pattern: "GET /encoded"}); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/encoded", nil))
+	if body := rec.Body.String(); body != `{"encoder":"generated"}`+"\n" {
+		t.Errorf("generated encoder not used: %s", body)
+	}
+}
+
+// bodyRequest is decoding its body like the generated decoders.
+type bodyRequest struct {
+	User user `body:"strict"`
+}
+
+func (r *bodyRequest) Decode(req *http.Request) error {
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	d := jsoncodec.NewDecoder(data, jsoncodec.DecoderOptions{Strict: true})
+	for key := range d.Object() {
+		switch key {
+		case "name":
+			jsoncodec.DecodeString(d, &r.User.Name)
+		default:
+			d.Unknown(key)
+		}
+	}
+	return d.Finish()
+}
+
+func TestHandlerFuncErr_DecodeBody(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	hl := func(ctx *nuage.Context, r *bodyRequest) (user, error) {
+		return r.User, nil
+	}
+	op := &openapi.Operation{Pattern: "POST /users"}
+	if err := nuage.Handle(n, hl, op); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	if op.RequestBody == nil || op.RequestBody.Content[nuage.ContentTypeJSON] == nil {
+		t.Fatalf("request body is not documented")
+	}
+	tests := []struct {
+		body    string
+		status  int
+		pointer string
+	}{
+		{body: `{"name":"jane"}`, status: http.StatusOK},
+		{body: `{"name":"\x"}`, status: http.StatusBadRequest, pointer: "/name"},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", nuage.ContentTypeJSON)
+		rec := httptest.NewRecorder()
+		n.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%s: status: got %d want %d", tc.body, rec.Code, tc.status)
+		}
+		if tc.status == http.StatusOK {
+			continue
+		}
+		var problem map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if problem["pointer"] != tc.pointer {
+			t.Errorf("%s: pointer: got %v want %s", tc.body, problem["pointer"], tc.pointer)
+		}
+	}
+}
+
+type idsRequest struct {
+	IDs []int
+}
+
+func (r *idsRequest) Decode(req *http.Request) error { return nil }
+
+func (r *idsRequest) Body() any { return &r.IDs }
+
+func TestHandlerFuncErr_Validation(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	strict := func(ctx *nuage.Context, r *bodyRequest) (user, error) {
+		t.Errorf("handler is called with invalid input: %+v", r.User)
+		return r.User, nil
+	}
+	if err := nuage.Handle(n, strict, &openapi.Operation{Pattern: "POST /users"}); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	loose := func(ctx *nuage.Context, r *createUserRequest) (user, error) {
+		return r.User, nil
+	}
+	if err := nuage.Handle(n, loose, &openapi.Operation{Pattern: "POST /tenants/{tenant}/users"}); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	ids := func(ctx *nuage.Context, r *idsRequest) (nuage.NoContent, error) {
+		return nuage.NoContent{}, nil
+	}
+	if err := nuage.Handle(n, ids, &openapi.Operation{Pattern: "POST /ids"}); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		want   []nuage.Violation
+	}{
+		{
+			name:   "strict body with unknown members",
+			path:   "/users",
+			body:   `{"email":"jane@example.com","name":"jane"}`,
+			status: http.StatusUnprocessableEntity,
+			want:   []nuage.Violation{{Pointer: "", Keyword: "additionalProperties"}},
+		},
+		{
+			name:   "body with unknown members",
+			path:   "/tenants/acme/users",
+			body:   `{"name":"jane","extra":1}`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "invalid items",
+			path:   "/ids",
+			body:   `[1,"2",true]`,
+			status: http.StatusUnprocessableEntity,
+			want: []nuage.Violation{
+				{Pointer: "/1", Keyword: "type"},
+				{Pointer: "/2", Keyword: "type"},
+			},
+		},
+		{
+			name:   "huge exponents",
+			path:   "/ids",
+			body:   `[` + strings.Repeat(`1e999999,`, 50) + `1]`,
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", nuage.ContentTypeJSON)
+			rec := httptest.NewRecorder()
+			n.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("status: got %d want %d: %s", rec.Code, tc.status, rec.Body)
+			}
+			if tc.want == nil {
+				return
+			}
+			var problem struct {
+				Type   string            `json:"type"`
+				Errors []nuage.Violation `json:"errors"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if problem.Type != nuage.ErrRequestBodyValidation.Type || len(problem.Errors) != len(tc.want) {
+				t.Fatalf("unexpected problem: %+v", problem)
+			}
+			for i, got := range problem.Errors {
+				if got.Pointer != tc.want[i].Pointer || got.Keyword != tc.want[i].Keyword || got.Message == "" {
+					t.Errorf("violation %d: got %+v want %+v", i, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+type searchRequest struct {
+	Query  string `query:"q,required"`
+	Limit  int    `query:"limit"`
+	Tenant string `header:"Tenant,required"`
+}
+
+// Decode is collecting the errors of all parameters like a generated decoder.
+func (r *searchRequest) Decode(req *http.Request) error {
+	var errs nuage.ParamErrors
+	q := req.URL.Query()
+	if !q.Has("q") {
+		errs = append(errs, &nuage.ParamError{Name: "q", In: "query", Reason: "is required"})
+	}
+	if req.Header.Get("Tenant") == "" {
+		errs = append(errs, &nuage.ParamError{Name: "Tenant", In: "header", Reason: "is required"})
+	}
+	if q.Has("limit") {
+		limit, err := strconv.Atoi(q.Get("limit"))
+		if err != nil {
+			errs = append(errs, &nuage.ParamError{Name: "limit", In: "query", Value: q.Get("limit"), Reason: "must be a valid int"})
+		}
+		r.Limit = limit
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	r.Query, r.Tenant = q.Get("q"), req.Header.Get("Tenant")
+	return nil
+}
+
+func TestHandlerFuncErr_ParamErrors(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	hl := func(ctx *nuage.Context, r *searchRequest) ([]user, error) {
+		t.Errorf("handler is called with missing parameters: %+v", r)
+		return nil, nil
+	}
+	op := &openapi.Operation{Pattern: "GET /search"}
+	if err := nuage.Handle(n, hl, op); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	if !op.Parameters[0].Required || op.Parameters[1].Required || !op.Parameters[2].Required {
+		t.Errorf("parameters are not documented as required")
+	}
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?limit=ten", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status: got %d want %d", rec.Code, http.StatusBadRequest)
+	}
+	var problem struct {
+		Type          string             `json:"type"`
+		InvalidParams []nuage.ParamError `json:"invalid-params"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := []nuage.ParamError{
+		{Name: "q", In: openapi.ParamInQuery, Reason: "is required"},
+		{Name: "Tenant", In: openapi.ParamInHeader, Reason: "is required"},
+		{Name: "limit", In: openapi.ParamInQuery, Value: "ten", Reason: "must be a valid int"},
+	}
+	if problem.Type != nuage.ErrParamInvalid.Type || !slices.Equal(problem.InvalidParams, want) {
+		t.Errorf("unexpected problem: %+v", problem)
+	}
+}
+
+type patchConfigRequest struct {
+	Patch jsonpatch.Patch
+}
+
+func (r *patchConfigRequest) Decode(req *http.Request) error { return nil }
+
+func (r *patchConfigRequest) Body() any { return &r.Patch }
+
+func TestHandlerFuncErr_JSONPatch(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	hl := func(ctx *nuage.Context, r *patchConfigRequest) (user, error) {
+		u := user{Name: "jane"}
+		err := jsonpatch.ApplyTo(&u, r.Patch)
+		return u, err
+	}
+	op := &openapi.Operation{Pattern: "PATCH /users/{id}"}
+	if err := nuage.Handle(n, hl, op); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	if op.RequestContentType != nuage.ContentTypeJSONPatch {
+		t.Errorf("request content type: got %s", op.RequestContentType)
+	}
+	if ref := op.RequestBody.Content[nuage.ContentTypeJSONPatch].Schema.Ref; ref != "#/components/schemas/JSONPatch" {
+		t.Errorf("request body ref: got %q", ref)
+	}
+	tests := []struct {
+		patch     string
+		status    int
+		operation float64
+	}{
+		{patch: `[{"op":"replace","path":"/name","value":"john"}]`, status: http.StatusOK},
+		{patch: `[{"op":"replace","path":"/name","value":"john"},{"op":"test","path":"/name","value":"jane"}]`, status: http.StatusConflict, operation: 1},
+		{patch: `[{"op":"remove","path":"/email"}]`, status: http.StatusUnprocessableEntity, operation: 0},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(tc.patch))
+		req.Header.Set("Content-Type", nuage.ContentTypeJSONPatch)
+		rec := httptest.NewRecorder()
+		n.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%s: status: got %d want %d", tc.patch, rec.Code, tc.status)
+		}
+		if tc.status == http.StatusOK {
+			continue
+		}
+		var problem map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if problem["operation"] != tc.operation {
+			t.Errorf("%s: operation: got %v want %v", tc.patch, problem["operation"], tc.operation)
+		}
+	}
+}
+
+func TestHandlerFuncErr_Context(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	var got *nuage.Context
+	hl := func(ctx *nuage.Context, r *emptyRequest) (nuage.NoContent, error) {
+		got = ctx
+		return nuage.NoContent{}, nil
+	}
+	op := &openapi.Operation{Pattern: "GET /ping", OperationID: "ping"}
+	if err := nuage.Handle(n, hl, op); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequestWithContext(reqCtx, http.MethodGet, "/ping", nil)
+	req.Header.Set(nuage.HeaderRequestID, "abc-123")
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, req)
+	if got.Operation() != op {
+		t.Errorf("operation is not available")
+	}
+	if got.Pattern() != op.Pattern {
+		t.Errorf("pattern: got %s want %s", got.Pattern(), op.Pattern)
+	}
+	if got.RequestID() != "abc-123" || rec.Header().Get(nuage.HeaderRequestID) != "abc-123" {
+		t.Errorf("request id is not propagated: %s", got.RequestID())
+	}
+	cancel()
+	if got.Err() == nil {
+		t.Errorf("cancellation of the request context is not propagated")
+	}
+}
+
+type tenantKey struct{}
+
+func TestHandlerFuncErr_ContextValue(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	tenant := func(next nuage.NextFunc) nuage.NextFunc {
+		return func(ctx *nuage.Context, w http.ResponseWriter, r *http.Request) error {
+			r = r.WithContext(context.WithValue(r.Context(), tenantKey{}, "acme"))
+			return next(ctx, w, r)
+		}
+	}
+	var got *nuage.Context
+	hl := func(ctx *nuage.Context, r *emptyRequest) (nuage.NoContent, error) {
+		got = ctx
+		return nuage.NoContent{}, nil
+	}
+	op := &openapi.Operation{Pattern: "GET /tenant", OperationID: "tenant"}
+	if err := nuage.Handle(n, hl, op, tenant); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/tenant", nil)
+	req.Header.Set(nuage.HeaderRequestID, "abc-123")
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status: got %d want %d", rec.Code, http.StatusNoContent)
+	}
+	if value := got.Value(tenantKey{}); value != "acme" {
+		t.Errorf("value of the middleware: got %v want acme", value)
+	}
+	if got.Request().Context().Value(tenantKey{}) != "acme" {
+		t.Errorf("value of the middleware is not available through the request")
+	}
+	if got.Operation() != op || got.RequestID() != "abc-123" {
+		t.Errorf("request metadata is not kept")
+	}
+	if nuage.NewCtx(got.Request()) != got {
+		t.Errorf("context of the request is not the context of the handler")
+	}
+}
+
+// captureStdout redirects stdout to the returned buffer while creating the
+// Nuage using fn. The buffer is filled once the returned func is called.
+func captureStdout(t *testing.T, fn func()) (*bytes.Buffer, func()) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	fn()
+	os.Stdout = stdout
+	var buf bytes.Buffer
+	copied := make(chan struct{})
+	go func() {
+		io.Copy(&buf, r)
+		close(copied)
+	}()
+	return &buf, func() {
+		w.Close()
+		<-copied
+		r.Close()
+	}
+}
+
+func TestHandlerFuncErr_AccessLog(t *testing.T) {
+	var n *nuage.Nuage
+	var err error
+	buf, flush := captureStdout(t, func() {
+		n, err = nuage.New()
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	hl := func(ctx *nuage.Context, r *emptyRequest) (user, error) {
+		return user{Name: "jane"}, nil
+	}
+	op := &openapi.Operation{Pattern: "GET /users/me", OperationID: "getMe"}
+	if err := nuage.Handle(n, hl, op); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/users/me", nil)
+	req.Header.Set(nuage.HeaderRequestID, "abc-123")
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, req)
+	size := rec.Body.Len()
+	// the request id is generated if the client is not providing one
+	rec = httptest.NewRecorder()
+	n.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/me", nil))
+	generatedID := rec.Header().Get(nuage.HeaderRequestID)
+	flush()
+
+	var records []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record map[string]any
+		if err := dec.Decode(&record); err != nil {
+			t.Fatalf("decode log record: %v", err)
+		}
+		if record["msg"] == "request served" {
+			records = append(records, record)
+		}
+	}
+	if len(records) != 2 {
+		t.Fatalf("access log records: got %d want 2", len(records))
+	}
+	for i, requestID := range []string{"abc-123", generatedID} {
+		record := records[i]
+		if record["request_id"] != requestID {
+			t.Errorf("request id: got %v want %s", record["request_id"], requestID)
+		}
+		if record["operation_id"] != op.OperationID {
+			t.Errorf("operation id: got %v want %s", record["operation_id"], op.OperationID)
+		}
+		if record["status"] != float64(http.StatusOK) {
+			t.Errorf("status: got %v want %d", record["status"], http.StatusOK)
+		}
+		if record["size"] != float64(size) {
+			t.Errorf("size: got %v want %d", record["size"], size)
+		}
+		if duration, isNumber := record["duration"].(float64); !isNumber || duration <= 0 {
+			t.Errorf("duration: got %v", record["duration"])
+		}
+	}
+	if generatedID == "" || generatedID == "abc-123" {
+		t.Errorf("request id is not generated: %q", generatedID)
+	}
+}
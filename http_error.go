@@ -2,13 +2,78 @@ package nuage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+
+	"github.com/naivary/nuage/jsonpatch"
 )
 
 var (
-	ErrJSONEncoding = &HTTPError{}
-	ErrUnauthorized = &HTTPError{}
-	ErrParamInvalid = &HTTPError{}
+	ErrJSONEncoding = &HTTPError{
+		Type:   "urn:nuage:problem:json-encoding",
+		Title:  "Response could not be encoded",
+		Status: http.StatusInternalServerError,
+	}
+	ErrUnauthorized = &HTTPError{
+		Type:   "urn:nuage:problem:unauthorized",
+		Title:  "Unauthorized",
+		Status: http.StatusUnauthorized,
+	}
+	ErrForbidden = &HTTPError{
+		Type:   "urn:nuage:problem:forbidden",
+		Title:  "Forbidden",
+		Status: http.StatusForbidden,
+	}
+	ErrParamInvalid = &HTTPError{
+		Type:   "urn:nuage:problem:param-invalid",
+		Title:  "Parameter is invalid",
+		Status: http.StatusBadRequest,
+	}
+	ErrInternal = &HTTPError{
+		Type:   "urn:nuage:problem:internal",
+		Title:  "Internal server error",
+		Status: http.StatusInternalServerError,
+	}
+
+	ErrRequestBodyMissing = &HTTPError{
+		Type:   "urn:nuage:problem:request-body-missing",
+		Title:  "Request body is missing",
+		Status: http.StatusBadRequest,
+	}
+	ErrRequestBodyInvalid = &HTTPError{
+		Type:   "urn:nuage:problem:request-body-invalid",
+		Title:  "Request body is invalid",
+		Status: http.StatusBadRequest,
+	}
+	ErrRequestBodyValidation = &HTTPError{
+		Type:   "urn:nuage:problem:request-body-validation",
+		Title:  "Request body violates the schema",
+		Status: http.StatusUnprocessableEntity,
+	}
+	ErrRequestBodyTooLarge = &HTTPError{
+		Type:   "urn:nuage:problem:request-body-too-large",
+		Title:  "Request body is too large",
+		Status: http.StatusRequestEntityTooLarge,
+	}
+	ErrUnsupportedMediaType = &HTTPError{
+		Type:   "urn:nuage:problem:unsupported-media-type",
+		Title:  "Unsupported media type",
+		Status: http.StatusUnsupportedMediaType,
+	}
+
+	ErrPatchConflict = &HTTPError{
+		Type:   "urn:nuage:problem:patch-conflict",
+		Title:  "Patch test operation failed",
+		Status: http.StatusConflict,
+	}
+	ErrPatchInvalid = &HTTPError{
+		Type:   "urn:nuage:problem:patch-invalid",
+		Title:  "Patch could not be applied",
+		Status: http.StatusUnprocessableEntity,
+	}
 )
 
 // HTTPError represents an error response formatted according to RFC 9457
@@ -45,12 +110,61 @@ type HTTPError struct {
 	// beyond the standard RFC 9457 members. This can include any
 	// extra metadata needed by the client or server.
 	Extensions map[string]any `json:"-"`
+
+	// Header contains additional header fields sent with the problem
+	// response e.g. WWW-Authenticate for 401 (Unauthorized).
+	Header http.Header `json:"-"`
 }
 
 func (e HTTPError) Error() string {
 	return fmt.Sprintf("%s:%s", e.Type, e.Detail)
 }
 
+// Is reports whether target is an HTTPError of the same problem type and
+// status allowing to match copies created by [HTTPError.WithDetail] using
+// [errors.Is].
+func (e HTTPError) Is(target error) bool {
+	var t *HTTPError
+	switch v := target.(type) {
+	case *HTTPError:
+		t = v
+	case HTTPError:
+		t = &v
+	default:
+		return false
+	}
+	return t != nil && e.Type == t.Type && e.Status == t.Status
+}
+
+// WithDetail returns a copy of e with the detail of this occurrence of the
+// problem set to detail.
+func (e HTTPError) WithDetail(detail string) *HTTPError {
+	e.Detail = detail
+	return &e
+}
+
+// WithHeader returns a copy of e with the header field key set to value in
+// the header sent with the problem response.
+func (e HTTPError) WithHeader(key, value string) *HTTPError {
+	e.Header = e.Header.Clone()
+	if e.Header == nil {
+		e.Header = make(http.Header, 1)
+	}
+	e.Header.Set(key, value)
+	return &e
+}
+
+// WithExtension returns a copy of e with the extension member key set to
+// value.
+func (e HTTPError) WithExtension(key string, value any) *HTTPError {
+	e.Extensions = maps.Clone(e.Extensions)
+	if e.Extensions == nil {
+		e.Extensions = make(map[string]any, 1)
+	}
+	e.Extensions[key] = value
+	return &e
+}
+
 func (e HTTPError) MarshalJSON() ([]byte, error) {
 	data := make(map[string]any)
 	if e.Type != "" {
@@ -78,3 +192,63 @@ func (e HTTPError) MarshalJSON() ([]byte, error) {
 	}
 	return json.Marshal(data)
 }
+
+// writeError writes err as a problem details response to w. Errors which are
+// not an HTTPError are logged and sanitized to [ErrInternal] to avoid leaking
+// implementation details to the client.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	httpErr := asHTTPError(err)
+	if httpErr == nil {
+		NewCtx(r).Logger().ErrorContext(r.Context(), "request failed", "err", err)
+		httpErr = ErrInternal
+	}
+	status := httpErr.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	data, err := json.Marshal(httpErr)
+	if err != nil {
+		NewCtx(r).Logger().ErrorContext(r.Context(), "problem details could not be encoded", "err", err)
+		data, _ = json.Marshal(ErrInternal)
+		status = ErrInternal.Status
+	}
+	for key, values := range httpErr.Header {
+		w.Header()[key] = values
+	}
+	w.Header().Set("Content-Type", ContentTypeHTTPError)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// asHTTPError returns the first HTTPError found in the tree of err or nil.
+// A failed JSON Patch is converted to [ErrPatchConflict] if a test operation
+// failed and to [ErrPatchInvalid] otherwise naming the failed operation.
+// Invalid parameters are converted to [ErrParamInvalid] listing all of them.
+func asHTTPError(err error) *HTTPError {
+	var ptr *HTTPError
+	if errors.As(err, &ptr) && ptr != nil {
+		return ptr
+	}
+	var val HTTPError
+	if errors.As(err, &val) {
+		return &val
+	}
+	var patchErr *jsonpatch.Error
+	if errors.As(err, &patchErr) {
+		httpErr := ErrPatchInvalid
+		if errors.Is(patchErr, jsonpatch.ErrTestFailed) {
+			httpErr = ErrPatchConflict
+		}
+		return httpErr.WithDetail(patchErr.Error()).WithExtension("operation", patchErr.Index)
+	}
+	var paramErrs ParamErrors
+	if errors.As(err, &paramErrs) {
+		return ErrParamInvalid.WithDetail(paramErrs.Error()).WithExtension("invalid-params", paramErrs)
+	}
+	var paramErr *ParamError
+	if errors.As(err, &paramErr) {
+		return ErrParamInvalid.WithDetail(paramErr.Error()).WithExtension("invalid-params", ParamErrors{paramErr})
+	}
+	return nil
+}
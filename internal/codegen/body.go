@@ -0,0 +1,276 @@
+package codegen
+
+import (
+	"fmt"
+	"go/types"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/naivary/nuage/internal/openapiutil"
+)
+
+// kinds of values in the generated body decoders
+const (
+	decBool        = "bool"
+	decInt         = "int"
+	decUint        = "uint"
+	decFloat       = "float"
+	decString      = "string"
+	decBytes       = "bytes"
+	decUnmarshaler = "unmarshaler"
+	decText        = "text"
+	decAny         = "any"
+	decPtr         = "ptr"
+	decSlice       = "slice"
+	decArray       = "array"
+	decMap         = "map"
+	decStruct      = "struct"
+	decFunc        = "func"
+)
+
+type body struct {
+	// Identifier of the field in the struct tagged as body
+	FieldIdent string
+
+	Opts *openapiutil.BodyOpts
+
+	Info *decoderInfo
+
+	// Funcs are the decode functions of the named types of the body
+	Funcs []*decodeFunc
+
+	// Imports of the packages of the named types decoded by Funcs
+	Imports []string
+}
+
+// decodeFunc is a generated function decoding a value of a named struct,
+// slice, array or map type. Named types are decoded by their function
+// allowing recursive types to be decoded without reflection.
+type decodeFunc struct {
+	// Identifier of the function
+	Ident string
+
+	// Type is the Go expression of the named type
+	Type string
+
+	Info *decoderInfo
+}
+
+// decodeFuncs are the decode functions of a body by the named types.
+type decodeFuncs struct {
+	// pkg is the package of the request model
+	pkg *types.Package
+
+	// prefix of the identifiers of the functions making them unique across
+	// the request models of pkg.
+	prefix string
+
+	funcs   map[string]*decodeFunc
+	order   []*decodeFunc
+	imports []string
+}
+
+// lookup returns the decode function of named. The function is registered
+// before its value is resolved to let recursive fields refer to it.
+func (fns *decodeFuncs) lookup(named *types.Named, resolve func() *decoderInfo) *decodeFunc {
+	typ := types.TypeString(named, types.RelativeTo(fns.pkg))
+	if fn, isDefined := fns.funcs[typ]; isDefined {
+		return fn
+	}
+	ident := "decode" + fns.prefix + named.Obj().Name()
+	for i := 2; slices.ContainsFunc(fns.order, func(fn *decodeFunc) bool { return fn.Ident == ident }); i++ {
+		ident = "decode" + fns.prefix + named.Obj().Name() + strconv.Itoa(i)
+	}
+	fn := &decodeFunc{
+		Ident: ident,
+		Type:  types.TypeString(named, func(p *types.Package) string { return fns.qualify(p) }),
+	}
+	fns.funcs[typ] = fn
+	fns.order = append(fns.order, fn)
+	fn.Info = resolve()
+	return fn
+}
+
+// qualify returns the name of p used in the generated code and imports p if
+// it is not the package of the request model.
+func (fns *decodeFuncs) qualify(p *types.Package) string {
+	if p == fns.pkg {
+		return ""
+	}
+	fns.imports = append(fns.imports, p.Path())
+	return p.Name()
+}
+
+type decoderInfo struct {
+	Kind string
+
+	// Bits is the size of integers and floats
+	Bits int
+
+	// Elem is the element of pointers, slices, arrays and maps.
+	Elem *decoderInfo
+
+	Fields []*decoderField
+
+	// Enum are the Go literals of the values of named types with typed
+	// constants.
+	Enum []string
+
+	// Func is the identifier of the decode function of named types
+	Func string
+}
+
+type decoderField struct {
+	// Key is the Go string literal of the key of the field
+	Key string
+
+	// Path of the field selector including the promoted fields of embedded
+	// structs.
+	Path string
+
+	// Quoted reports whether the value is decoded from a JSON string
+	Quoted bool
+
+	Info *decoderInfo
+}
+
+// genBody returns the body of the request model declared in pkg if the field
+// is tagged as body.
+func genBody(pkg *types.Package, model string, field *types.Var, tag reflect.StructTag) (*body, error) {
+	opts, err := openapiutil.ParseBodyOpts(tag)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", field.Name(), err)
+	}
+	if opts == nil {
+		return nil, nil
+	}
+	if openapiutil.ParamLocation(tag) != "" {
+		return nil, fmt.Errorf("%s: body can not be a parameter", field.Name())
+	}
+	fns := &decodeFuncs{
+		pkg:    pkg,
+		prefix: model,
+		funcs:  make(map[string]*decodeFunc),
+	}
+	return &body{
+		FieldIdent: field.Name(),
+		Opts:       opts,
+		Info:       resolveDecoder(pkg, field.Type(), make(map[*types.Named]bool), fns),
+		Funcs:      fns.order,
+		Imports:    fns.imports,
+	}, nil
+}
+
+// resolveDecoder returns the information needed to decode values of typ
+// following the rules of encoding/json. Named struct, slice, array and map
+// types are decoded by a function of fns. Values which cannot be decoded
+// without reflection e.g. interfaces or recursive types which can not be
+// referred to are of kind any. The values of the enums of pkg are checked.
+func resolveDecoder(pkg *types.Package, typ types.Type, seen map[*types.Named]bool, fns *decodeFuncs) *decoderInfo {
+	if _, isInterface := typ.Underlying().(*types.Interface); isInterface {
+		return &decoderInfo{Kind: decAny}
+	}
+	if _, isPtr := typ.(*types.Pointer); !isPtr {
+		switch ptr := types.NewPointer(typ); {
+		case hasMethod(ptr, "UnmarshalJSON"):
+			return &decoderInfo{Kind: decUnmarshaler}
+		case hasMethod(ptr, "UnmarshalText"):
+			return &decoderInfo{Kind: decText}
+		}
+	}
+	if named, isNamed := typ.(*types.Named); isNamed {
+		if isDecodeFuncType(pkg, named) {
+			// recursive fields are calling the function which is already
+			// registered
+			fn := fns.lookup(named, func() *decoderInfo {
+				return resolveDecoder(pkg, named.Underlying(), seen, fns)
+			})
+			return &decoderInfo{Kind: decFunc, Func: fn.Ident}
+		}
+		if seen[named] {
+			return &decoderInfo{Kind: decAny}
+		}
+		seen[named] = true
+		defer delete(seen, named)
+		if consts := enumConsts(pkg, named); len(consts) > 0 {
+			info := resolveDecoder(pkg, named.Underlying(), seen, fns)
+			info.Enum = enumLiterals(consts)
+			return info
+		}
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		info := t.Info()
+		switch {
+		case info&types.IsBoolean != 0:
+			return &decoderInfo{Kind: decBool}
+		case info&types.IsString != 0:
+			return &decoderInfo{Kind: decString}
+		case info&types.IsUnsigned != 0:
+			return &decoderInfo{Kind: decUint, Bits: bitSize(t.Name())}
+		case info&types.IsInteger != 0:
+			return &decoderInfo{Kind: decInt, Bits: bitSize(t.Name())}
+		case info&types.IsFloat != 0:
+			return &decoderInfo{Kind: decFloat, Bits: bitSize(t.Name())}
+		}
+		return &decoderInfo{Kind: decAny}
+	case *types.Pointer:
+		return &decoderInfo{Kind: decPtr, Elem: resolveDecoder(pkg, t.Elem(), seen, fns)}
+	case *types.Slice:
+		if isByte(t.Elem()) {
+			return &decoderInfo{Kind: decBytes}
+		}
+		return &decoderInfo{Kind: decSlice, Elem: resolveDecoder(pkg, t.Elem(), seen, fns)}
+	case *types.Array:
+		return &decoderInfo{Kind: decArray, Elem: resolveDecoder(pkg, t.Elem(), seen, fns)}
+	case *types.Map:
+		key, isBasic := t.Key().Underlying().(*types.Basic)
+		if !isBasic || key.Info()&types.IsString == 0 {
+			return &decoderInfo{Kind: decAny}
+		}
+		return &decoderInfo{Kind: decMap, Elem: resolveDecoder(pkg, t.Elem(), seen, fns)}
+	case *types.Struct:
+		fields, isSupported := jsonFields(t)
+		if !isSupported {
+			return &decoderInfo{Kind: decAny}
+		}
+		info := &decoderInfo{Kind: decStruct, Fields: make([]*decoderField, 0, len(fields))}
+		for _, f := range fields {
+			info.Fields = append(info.Fields, &decoderField{
+				Key:    strconv.Quote(f.Name),
+				Path:   f.Path,
+				Quoted: slices.Contains(f.Opts, "string") && isQuotable(f.Var.Type()),
+				Info:   resolveDecoder(pkg, f.Var.Type(), seen, fns),
+			})
+		}
+		return info
+	default:
+		return &decoderInfo{Kind: decAny}
+	}
+}
+
+// isDecodeFuncType reports whether values of named are decoded by a generated
+// function. The type has to be a struct, slice, array or map which can be
+// referred to from pkg.
+func isDecodeFuncType(pkg *types.Package, named *types.Named) bool {
+	obj := named.Obj()
+	if obj.Pkg() == nil || (obj.Pkg() != pkg && !obj.Exported()) {
+		return false
+	}
+	// instances of generic types are inlined
+	if named.TypeArgs().Len() > 0 {
+		return false
+	}
+	switch t := named.Underlying().(type) {
+	case *types.Struct:
+		_, isSupported := jsonFields(t)
+		return isSupported
+	case *types.Slice:
+		return !isByte(t.Elem())
+	case *types.Array, *types.Map:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,263 @@
+package codegen
+
+import (
+	"fmt"
+	"go/types"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/naivary/nuage/internal/openapiutil"
+	"github.com/naivary/nuage/openapi"
+)
+
+// check is a constraint of a parameter compiled into the generated decoder.
+type check struct {
+	// Cond is the condition under which the constraint is violated
+	Cond string
+
+	// Reason is the Go string literal of the reason reported in the
+	// ParamError.
+	Reason string
+}
+
+// pattern is a regular expression precompiled at package level
+type pattern struct {
+	// Ident is the identifier of the package level variable
+	Ident string
+
+	// Expr is the Go string literal of the regular expression
+	Expr string
+}
+
+// genChecks compiles the constraints of param into checks of the value of the
+// field after it has been decoded. The values of named types with typed
+// constants are checked to be one of consts. The imports needed by the checks
+// are added to r.
+func genChecks(r *requestModel, param *parameter, consts []*types.Const) error {
+	opts := param.Opts
+	if !opts.HasConstraints() && len(consts) == 0 {
+		return nil
+	}
+	switch param.In {
+	case openapi.ParamInPath:
+		param.Raw = fmt.Sprintf("req.PathValue(%q)", param.Ident)
+		param.Present = param.Raw + ` != ""`
+	case openapi.ParamInQuery:
+		param.Raw = fmt.Sprintf("q.Get(%q)", param.Ident)
+		param.Present = fmt.Sprintf("q.Has(%q)", param.Ident)
+	case openapi.ParamInHeader:
+		param.Raw = fmt.Sprintf("req.Header.Get(%q)", param.Ident)
+		param.Present = param.Raw + ` != ""`
+	default:
+		return fmt.Errorf("%s: constraints are not supported for %s parameters", param.FieldIdent, param.In)
+	}
+	if isParsed(param.TypeInfo) {
+		// values which could not be parsed are reported already
+		param.Present += fmt.Sprintf(" && !errs.Contains(%q, %q)", param.Ident, param.In)
+	}
+	// dereference the pointers of the field to get to the value
+	value := "r." + param.FieldIdent
+	info := param.TypeInfo
+	for info.Kind == kindPtr || info.Kind == kindNamed {
+		if info.Kind == kindPtr {
+			param.Present += fmt.Sprintf(" && %s != nil", value)
+			value = "*" + value
+		}
+		info = info.Children[0]
+	}
+	param.Value = value
+
+	elem := info
+	if info.Kind == kindSlice {
+		elem = underlyingInfo(info.Children[0])
+		if opts.MinItems != nil {
+			param.Checks = append(param.Checks, &check{
+				Cond:   fmt.Sprintf("len(%s) < %d", value, *opts.MinItems),
+				Reason: reason("must have at least %d value(s)", *opts.MinItems),
+			})
+		}
+		if opts.MaxItems != nil {
+			param.Checks = append(param.Checks, &check{
+				Cond:   fmt.Sprintf("len(%s) > %d", value, *opts.MaxItems),
+				Reason: reason("must have at most %d value(s)", *opts.MaxItems),
+			})
+		}
+		value = "v"
+	} else if opts.MinItems != nil || opts.MaxItems != nil {
+		return fmt.Errorf("%s: minItems and maxItems are only supported by arrays", param.FieldIdent)
+	}
+	if !isBasic(elem) {
+		return fmt.Errorf("%s: constraints are not supported by %s", param.FieldIdent, elem.Kind)
+	}
+	schema, err := openapiutil.ScalarParamSchema(jsonKind(elem.Kind), opts)
+	if err != nil {
+		return err
+	}
+	checks := make([]*check, 0, 1)
+	if opts.Minimum != nil {
+		bound, err := formatBound(elem.Kind, *opts.Minimum)
+		if err != nil {
+			return fmt.Errorf("%s: min: %w", param.FieldIdent, err)
+		}
+		checks = append(checks, &check{
+			Cond:   compare(elem.Kind, value, "<", bound),
+			Reason: reason("must be greater than or equal to %s", formatFloat(*opts.Minimum)),
+		})
+	}
+	if opts.Maximum != nil {
+		bound, err := formatBound(elem.Kind, *opts.Maximum)
+		if err != nil {
+			return fmt.Errorf("%s: max: %w", param.FieldIdent, err)
+		}
+		checks = append(checks, &check{
+			Cond:   compare(elem.Kind, value, ">", bound),
+			Reason: reason("must be less than or equal to %s", formatFloat(*opts.Maximum)),
+		})
+	}
+	if m := opts.MultipleOf; m != nil {
+		cond := fmt.Sprintf("math.Mod(float64(%s), %s) != 0", value, formatFloat(*m))
+		if isInteger(elem.Kind) {
+			divisor, err := formatBound(elem.Kind, *m)
+			if err != nil {
+				return fmt.Errorf("%s: multipleOf: %w", param.FieldIdent, err)
+			}
+			cond = fmt.Sprintf("%s%%%s != 0", value, divisor)
+		} else {
+			r.Imports = append(r.Imports, "math")
+		}
+		checks = append(checks, &check{
+			Cond:   cond,
+			Reason: reason("must be a multiple of %s", formatFloat(*m)),
+		})
+	}
+	if opts.MinLength != nil {
+		r.Imports = append(r.Imports, "unicode/utf8")
+		checks = append(checks, &check{
+			Cond:   fmt.Sprintf("utf8.RuneCountInString(string(%s)) < %d", value, *opts.MinLength),
+			Reason: reason("must be at least %d characters long", *opts.MinLength),
+		})
+	}
+	if opts.MaxLength != nil {
+		r.Imports = append(r.Imports, "unicode/utf8")
+		checks = append(checks, &check{
+			Cond:   fmt.Sprintf("utf8.RuneCountInString(string(%s)) > %d", value, *opts.MaxLength),
+			Reason: reason("must be at most %d characters long", *opts.MaxLength),
+		})
+	}
+	if opts.Pattern != "" {
+		p := &pattern{
+			Ident: "pattern" + r.Ident + param.FieldIdent,
+			Expr:  strconv.Quote(opts.Pattern),
+		}
+		r.Patterns = append(r.Patterns, p)
+		r.Imports = append(r.Imports, "regexp")
+		checks = append(checks, &check{
+			Cond:   fmt.Sprintf("!%s.MatchString(string(%s))", p.Ident, value),
+			Reason: reason("must match the pattern %s", opts.Pattern),
+		})
+	}
+	if len(schema.Enum) > 0 {
+		literals := make([]string, 0, len(schema.Enum))
+		for _, v := range schema.Enum {
+			literal := fmt.Sprint(v)
+			if s, isString := v.(string); isString {
+				literal = strconv.Quote(s)
+			}
+			literals = append(literals, literal)
+		}
+		checks = append(checks, &check{
+			Cond:   notOneOf(value, literals),
+			Reason: reason("must be one of %s", strings.Join(opts.Enum, ", ")),
+		})
+	}
+	if len(consts) > 0 {
+		checks = append(checks, &check{
+			Cond:   notOneOf(value, enumLiterals(consts)),
+			Reason: reason("must be one of %s", strings.Join(enumValues(consts), ", ")),
+		})
+	}
+	if info.Kind == kindSlice {
+		param.ItemChecks = checks
+		r.Imports = append(r.Imports, "fmt")
+	} else {
+		param.Checks = checks
+	}
+	return nil
+}
+
+// notOneOf returns the condition under which value is none of literals.
+func notOneOf(value string, literals []string) string {
+	conds := make([]string, 0, len(literals))
+	for _, literal := range literals {
+		conds = append(conds, fmt.Sprintf("%s != %s", value, literal))
+	}
+	return strings.Join(conds, " && ")
+}
+
+// underlyingInfo returns the type information of the basic type underlying
+// named types.
+func underlyingInfo(info *typeInfo) *typeInfo {
+	for info.Kind == kindNamed {
+		info = info.Children[0]
+	}
+	return info
+}
+
+// jsonKind returns the JSON type of values of the basic type kind.
+func jsonKind(kind string) string {
+	switch {
+	case kind == "bool":
+		return "boolean"
+	case isInteger(kind):
+		return "integer"
+	case kind == "float32" || kind == "float64":
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// compare returns the condition comparing value with bound using op. Integers
+// are compared in their own type to avoid the loss of precision of float64.
+func compare(kind, value, op, bound string) string {
+	if isInteger(kind) {
+		return fmt.Sprintf("%s %s %s", value, op, bound)
+	}
+	return fmt.Sprintf("float64(%s) %s %s", value, op, bound)
+}
+
+// formatBound returns the Go literal of the bound b of values of the basic
+// type kind. Bounds of integers have to be integers representable by kind.
+func formatBound(kind string, b float64) (string, error) {
+	if !isInteger(kind) {
+		return formatFloat(b), nil
+	}
+	if b != math.Trunc(b) || math.IsInf(b, 0) {
+		return "", fmt.Errorf("%s is not an integer", formatFloat(b))
+	}
+	bits := uint(bitSize(kind))
+	lo, hi := new(big.Int), new(big.Int).Lsh(big.NewInt(1), bits)
+	if strings.HasPrefix(kind, "uint") {
+		hi.Sub(hi, big.NewInt(1))
+	} else {
+		hi.Rsh(hi, 1)
+		lo.Neg(hi)
+		hi.Sub(hi, big.NewInt(1))
+	}
+	v, _ := big.NewFloat(b).Int(nil)
+	if v.Cmp(lo) < 0 || v.Cmp(hi) > 0 {
+		return "", fmt.Errorf("%s is out of range of %s", formatFloat(b), kind)
+	}
+	return v.String(), nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// reason returns the Go string literal of the reason of a violated constraint.
+func reason(format string, args ...any) string {
+	return strconv.Quote(fmt.Sprintf(format, args...))
+}
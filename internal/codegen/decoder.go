@@ -11,6 +11,7 @@ import (
 	"go/types"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
 	"text/template"
 
@@ -31,6 +32,9 @@ type requestModel struct {
 	// Import statments defined by the request model
 	Imports []string
 
+	// Imports of the standard library
+	StdImports []string
+
 	// Identifier
 	Ident string
 
@@ -39,6 +43,24 @@ type requestModel struct {
 
 	// Parameters infered from the fields of the request model
 	Parameters []*parameter
+
+	// Body of the request model if a field is tagged as body
+	Body *body
+
+	// UsesStrconv reports whether parameters have to be parsed
+	UsesStrconv bool
+
+	// UsesErrors reports whether optional cookies are defined
+	UsesErrors bool
+
+	// Patterns of the parameters precompiled at package level
+	Patterns []*pattern
+
+	// Required are the parameters which have to be present
+	Required []*parameter
+
+	// Enums are the named types for which the Enum method is generated
+	Enums []*enum
 }
 
 type parameter struct {
@@ -49,12 +71,44 @@ type parameter struct {
 	// parameter is found.
 	FieldIdent string
 
+	// Var is the identifier of the local variable holding the raw value of
+	// the parameter. The Ident might not be a valid Go identifier or shadow
+	// an identifier used by the decoder.
+	Var string
+
 	// Location of the parameter
 	In openapi.ParamIn
 
 	TypeInfo *typeInfo
 
 	Opts *openapiutil.ParamOpts
+
+	// Missing is the condition under which the required parameter was not
+	// provided.
+	Missing string
+
+	// Absent is the condition under which the parameter was not provided
+	// and the default is assigned.
+	Absent string
+
+	// Default is the Go expression of the default value of the field
+	Default string
+
+	// Present is the condition under which the parameter was provided and
+	// its value has to satisfy the constraints.
+	Present string
+
+	// Raw is the expression of the raw value of the parameter
+	Raw string
+
+	// Value is the expression of the dereferenced value of the field
+	Value string
+
+	// Checks of the value compiled from the constraints of the parameter
+	Checks []*check
+
+	// ItemChecks of every value of an array
+	ItemChecks []*check
 }
 
 type typeInfo struct {
@@ -87,6 +141,8 @@ func GenDecoder(args []string) error {
 		return errors.New("GenDecoder: error while loading packages")
 	}
 	for _, pkg := range pkgs {
+		// named types of pkg for which the Enum method is generated
+		enums := make(map[*types.Named]bool)
 		for _, file := range pkg.Syntax {
 			for _, decl := range file.Decls {
 				genDecl, isGenDecl := decl.(*ast.GenDecl)
@@ -101,7 +157,7 @@ func GenDecoder(args []string) error {
 					if s == nil {
 						continue
 					}
-					data, err := genDecoder(pkg, ident, s)
+					data, err := genDecoder(pkg, ident, s, enums)
 					if err != nil {
 						return err
 					}
@@ -129,7 +185,7 @@ func GenDecoder(args []string) error {
 	return nil
 }
 
-func genDecoder(pkg *packages.Package, ident string, s *types.Struct) (*requestModel, error) {
+func genDecoder(pkg *packages.Package, ident string, s *types.Struct, enums map[*types.Named]bool) (*requestModel, error) {
 	r := requestModel{
 		PkgName:    pkg.Name,
 		Ident:      ident,
@@ -139,8 +195,25 @@ func genDecoder(pkg *packages.Package, ident string, s *types.Struct) (*requestM
 	for i := range s.NumFields() {
 		tag := reflect.StructTag(s.Tag(i))
 		field := s.Field(i)
+		b, err := genBody(pkg.Types, ident, field, tag)
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			if r.Body != nil {
+				return nil, fmt.Errorf("only one field can be tagged as body: %s", field.Name())
+			}
+			r.Body = b
+			if err := genEnums(&r, pkg, field.Type(), enums); err != nil {
+				return nil, err
+			}
+			r.Imports = append(r.Imports, "io", "github.com/naivary/nuage/jsoncodec")
+			r.Imports = append(r.Imports, b.Imports...)
+			continue
+		}
 		param := parameter{
 			FieldIdent: field.Name(),
+			Var:        "raw" + field.Name(),
 			In:         openapiutil.ParamLocation(tag),
 		}
 		if param.In == "" {
@@ -155,6 +228,9 @@ func genDecoder(pkg *packages.Package, ident string, s *types.Struct) (*requestM
 		param.Opts = opts
 
 		typ := field.Type()
+		if param.In == openapi.ParamInCookie && !isSupportedCookieParamType(typ) {
+			return nil, fmt.Errorf("%s: cookie parameters have to be of type *http.Cookie", field.Name())
+		}
 		if !isSupportedParamType(opts, typ) {
 			return nil, fmt.Errorf("paramater type is not supported: %s", field.Name())
 		}
@@ -163,12 +239,58 @@ func genDecoder(pkg *packages.Package, ident string, s *types.Struct) (*requestM
 			return nil, fmt.Errorf("type information can not be extracted: %s", field.Name())
 		}
 		param.TypeInfo = info
+		genRequired(&r, &param)
+		consts := enumConsts(pkg.Types, paramEnum(pkg.Types, typ))
+		if err := genDefault(pkg.Name, &param, consts); err != nil {
+			return nil, err
+		}
+		if err := genChecks(&r, &param, consts); err != nil {
+			return nil, err
+		}
+		if err := genEnums(&r, pkg, typ, enums); err != nil {
+			return nil, err
+		}
 		r.Imports = append(r.Imports, resolveImports(pkg, info)...)
+		if param.In == openapi.ParamInQuery && derefInfo(info).Kind == kindSlice && !opts.Explode {
+			r.Imports = append(r.Imports, "strings")
+		}
 		r.Parameters = append(r.Parameters, &param)
+		r.UsesStrconv = r.UsesStrconv || isParsed(info)
+		r.UsesErrors = r.UsesErrors || (param.In == openapi.ParamInCookie && !opts.Required)
 	}
+	slices.Sort(r.Imports)
+	r.Imports = slices.Compact(r.Imports)
+	r.StdImports = slices.DeleteFunc(slices.Clone(r.Imports), func(path string) bool {
+		return strings.Contains(path, ".")
+	})
+	r.Imports = slices.DeleteFunc(r.Imports, func(path string) bool {
+		return !strings.Contains(path, ".")
+	})
 	return &r, nil
 }
 
+// derefInfo returns the type information of the type underlying pointers and
+// named types.
+func derefInfo(info *typeInfo) *typeInfo {
+	for info.Kind == kindPtr || info.Kind == kindNamed {
+		info = info.Children[0]
+	}
+	return info
+}
+
+// isParsed reports whether the value of the parameter has to be parsed using
+// strconv.
+func isParsed(info *typeInfo) bool {
+	switch {
+	case isInteger(info.Kind) || info.Kind == "bool":
+		return true
+	case info.Kind == kindStruct:
+		// structs like http.Cookie are assigned as a whole
+		return false
+	}
+	return slices.ContainsFunc(info.Children, isParsed)
+}
+
 // isRequestModel reports whether `spec` is a request model in the context
 // of nuage and should be considered for generation of code.
 func isRequestModel(pkg *packages.Package, spec ast.Spec) (string, *types.Struct) {
@@ -1,14 +1,154 @@
 package codegen_test
 
 import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/naivary/nuage/internal/codegen"
 )
 
+// header is starting every generated file
+const header = "// Code generated by nuage. DO NOT EDIT.\n"
+
+// generate runs gen for the testdata and returns the generated files.
+func generate(t *testing.T, gen func(args []string) error, args ...string) []string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+	stdout := os.Stdout
+	os.Stdout = w
+	err = gen(append(args, "./testdata"))
+	os.Stdout = stdout
+	w.Close()
+	generated := <-output
+	if err != nil {
+		t.Fatalf("codegen: %v", err)
+	}
+	files := strings.Split(generated, header)[1:]
+	for i, file := range files {
+		files[i] = header + file
+	}
+	return files
+}
+
+// compile writes the testdata, the generated files and the test file of the
+// testdata named test into a temporary module. The module is vetted and the
+// tests are run to compare the generated code with encoding/json.
+func compile(t *testing.T, files []string, test string) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("compiling the generated code is skipped in short mode")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	root, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatalf("module root: %v", err)
+	}
+	dir := t.TempDir()
+	gomod := "module example.com/testdata\n\ngo 1.25\n\n" +
+		"require github.com/naivary/nuage v0.0.0\n\n" +
+		"replace github.com/naivary/nuage => " + root + "\n"
+	copies := map[string]string{
+		"main.go": "testdata/main.go",
+		test:      filepath.Join("testdata", test),
+		"go.sum":  filepath.Join(root, "go.sum"),
+	}
+	for name, src := range copies {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	for i, file := range files {
+		name := filepath.Join(dir, "generated_"+strconv.Itoa(i)+".go")
+		if err := os.WriteFile(name, []byte(file), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	for _, args := range [][]string{{"vet", "."}, {"test", "."}} {
+		cmd := exec.Command(goBin, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("go %s: %v\n%s", args[0], err, out)
+		}
+	}
+}
+
 func TestGenDecoder(t *testing.T) {
-	err := codegen.GenDecoder([]string{"./testdata"})
+	files := generate(t, codegen.GenDecoder)
+	compile(t, files, "decoder_test.go")
+}
+
+func TestGenDecoder_InvalidDefault(t *testing.T) {
+	dirs, err := filepath.Glob("testdata/defaults/*")
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	for _, dir := range dirs {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			if err := codegen.GenDecoder([]string{"./" + dir}); err == nil {
+				t.Errorf("expected an error for the invalid default")
+			}
+		})
+	}
+}
+
+func TestGenDecoder_InvalidConstraint(t *testing.T) {
+	dirs, err := filepath.Glob("testdata/constraints/*")
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	for _, dir := range dirs {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			if err := codegen.GenDecoder([]string{"./" + dir}); err == nil {
+				t.Errorf("expected an error for the invalid constraint")
+			}
+		})
+	}
+}
+
+func TestGenDecoder_InvalidEnum(t *testing.T) {
+	dirs, err := filepath.Glob("testdata/enums/*")
 	if err != nil {
-		t.Errorf("codegen: %v", err)
+		t.Fatalf("glob: %v", err)
+	}
+	for _, dir := range dirs {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			if err := codegen.GenDecoder([]string{"./" + dir}); err == nil {
+				t.Errorf("expected an error for the declared Enum method")
+			}
+		})
 	}
 }
+
+func TestGenEncoder(t *testing.T) {
+	files := generate(t, codegen.GenEncoder)
+	compile(t, files, "encoder_test.go")
+}
+
+func TestGenPatch(t *testing.T) {
+	files := generate(t, codegen.GenPatch, "-type=User")
+	compile(t, files, "patch_test.go")
+}
@@ -0,0 +1,190 @@
+package codegen
+
+import (
+	"fmt"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/naivary/nuage/internal/openapiutil"
+	"github.com/naivary/nuage/openapi"
+)
+
+// genRequired sets the condition under which the required parameter param is
+// missing. The presence of path parameters is guaranteed by the pattern.
+func genRequired(r *requestModel, param *parameter) {
+	if !param.Opts.Required {
+		return
+	}
+	switch param.In {
+	case openapi.ParamInQuery:
+		param.Missing = fmt.Sprintf("!q.Has(%q)", param.Ident)
+	case openapi.ParamInHeader:
+		param.Missing = fmt.Sprintf("req.Header.Get(%q) == \"\"", param.Ident)
+	case openapi.ParamInCookie:
+		param.Missing = fmt.Sprintf("_, err := req.Cookie(%q); err != nil", param.Ident)
+	default:
+		return
+	}
+	r.Required = append(r.Required, param)
+}
+
+// genDefault converts the default value of param to the Go expression of the
+// type of the field which is assigned if the parameter is absent. The default
+// has to satisfy the constraints of param and be one of consts if defined.
+func genDefault(pkgName string, param *parameter, consts []*types.Const) error {
+	value, isString := param.Opts.Default.(string)
+	if !isString {
+		return nil
+	}
+	switch param.In {
+	case openapi.ParamInQuery:
+		param.Absent = fmt.Sprintf("!q.Has(%q)", param.Ident)
+	case openapi.ParamInHeader:
+		param.Absent = fmt.Sprintf("req.Header.Get(%q) == \"\"", param.Ident)
+	case openapi.ParamInCookie:
+		// cookie parameters are of type *http.Cookie
+		param.Absent = fmt.Sprintf("r.%s == nil", param.FieldIdent)
+		param.Default = fmt.Sprintf("&http.Cookie{Name: %q, Value: %q}", param.Ident, value)
+		return checkDefault(param, consts)
+	default:
+		return fmt.Errorf("%s: default is not supported for %s parameters", param.FieldIdent, param.In)
+	}
+	if err := checkDefault(param, consts); err != nil {
+		return err
+	}
+	expr, err := defaultExpr(pkgName, param.TypeInfo, value)
+	if err != nil {
+		return fmt.Errorf("%s: default: %w", param.FieldIdent, err)
+	}
+	param.Default = expr
+	return nil
+}
+
+// checkDefault checks the default value of param against the schema of the
+// parameter as it is done at runtime when the operation is registered. The
+// values of cookies are strings.
+func checkDefault(param *parameter, consts []*types.Const) error {
+	if param.In == openapi.ParamInCookie {
+		s, err := openapiutil.ScalarParamSchema("string", param.Opts)
+		if err != nil {
+			return err
+		}
+		_, err = openapiutil.ParamDefault(s, param.Opts)
+		return err
+	}
+	info := derefInfo(param.TypeInfo)
+	elem := info
+	if info.Kind == kindSlice {
+		elem = underlyingInfo(info.Children[0])
+	}
+	if !isBasic(elem) {
+		// defaultExpr reports the unsupported type
+		return nil
+	}
+	s, err := openapiutil.ScalarParamSchema(jsonKind(elem.Kind), param.Opts)
+	if err != nil {
+		return err
+	}
+	if len(s.Enum) == 0 && len(consts) > 0 {
+		s.Enum = constValues(consts)
+	}
+	if info.Kind == kindSlice {
+		s = &jsonschema.Schema{
+			Type:     "array",
+			Items:    s,
+			MinItems: param.Opts.MinItems,
+			MaxItems: param.Opts.MaxItems,
+		}
+	}
+	_, err = openapiutil.ParamDefault(s, param.Opts)
+	return err
+}
+
+// defaultExpr returns the Go expression of value converted to the type
+// described by info. The values of slices are separated by `|`.
+func defaultExpr(pkgName string, info *typeInfo, value string) (string, error) {
+	switch info.Kind {
+	case kindPtr:
+		elem, err := defaultExpr(pkgName, info.Children[0], value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("nuage.Ptr(%s)", elem), nil
+	case kindNamed:
+		underlying, err := untypedExpr(pkgName, info.Children[0], value)
+		if err != nil {
+			return "", err
+		}
+		ident := info.Ident
+		if info.Pkg != pkgName {
+			ident = info.Pkg + "." + ident
+		}
+		return fmt.Sprintf("%s(%s)", ident, underlying), nil
+	case kindSlice:
+		elem := info.Children[0]
+		values := make([]string, 0, 1)
+		for v := range strings.SplitSeq(value, "|") {
+			expr, err := untypedExpr(pkgName, elem, v)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, expr)
+		}
+		return fmt.Sprintf("[]%s{%s}", elemType(elem), strings.Join(values, ", ")), nil
+	}
+	lit, err := literal(info.Kind, value)
+	if err != nil {
+		return "", err
+	}
+	switch info.Kind {
+	case "string", "bool", "int":
+		return lit, nil
+	default:
+		return fmt.Sprintf("%s(%s)", info.Kind, lit), nil
+	}
+}
+
+// untypedExpr returns the untyped literal of value if info is a basic type.
+// Otherwise the expression returned by defaultExpr is used.
+func untypedExpr(pkgName string, info *typeInfo, value string) (string, error) {
+	if isBasic(info) {
+		return literal(info.Kind, value)
+	}
+	return defaultExpr(pkgName, info, value)
+}
+
+// literal returns the Go literal of value parsed as the basic type kind.
+func literal(kind, value string) (string, error) {
+	switch kind {
+	case "string":
+		return strconv.Quote(value), nil
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("value is not a bool: %s", value)
+		}
+		return strconv.FormatBool(b), nil
+	case "int", "int8", "int16", "int32", "int64":
+		i, err := strconv.ParseInt(value, 10, bitSize(kind))
+		if err != nil {
+			return "", fmt.Errorf("value is not an %s: %s", kind, value)
+		}
+		return strconv.FormatInt(i, 10), nil
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		u, err := strconv.ParseUint(value, 10, bitSize(kind))
+		if err != nil {
+			return "", fmt.Errorf("value is not an %s: %s", kind, value)
+		}
+		return strconv.FormatUint(u, 10), nil
+	case "float32", "float64":
+		f, err := strconv.ParseFloat(value, bitSize(kind))
+		if err != nil {
+			return "", fmt.Errorf("value is not a %s: %s", kind, value)
+		}
+		return strconv.FormatFloat(f, 'g', -1, bitSize(kind)), nil
+	default:
+		return "", fmt.Errorf("not supported by %s", kind)
+	}
+}
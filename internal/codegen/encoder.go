@@ -0,0 +1,527 @@
+package codegen
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// kinds of values in the generated encoders
+const (
+	encBool      = "bool"
+	encInt       = "int"
+	encUint      = "uint"
+	encFloat     = "float"
+	encString    = "string"
+	encBytes     = "bytes"
+	encTime      = "time"
+	encMarshaler = "marshaler"
+	encText      = "text"
+	encAny       = "any"
+	encPtr       = "ptr"
+	encSlice     = "slice"
+	encArray     = "array"
+	encMap       = "map"
+	encStruct    = "struct"
+)
+
+type responseModel struct {
+	// Package in which the response model was found
+	PkgName string
+
+	// Identifier
+	Ident string
+
+	// Expr is the expression of the value of the receiver
+	Expr string
+
+	// Fallible reports whether the encoding can fail
+	Fallible bool
+
+	Info *encoderInfo
+}
+
+type encoderInfo struct {
+	Kind string
+
+	// Bits is the size of floats
+	Bits int
+
+	// Addr reports whether the marshaler is implemented by the pointer
+	// receiver.
+	Addr bool
+
+	// Elem is the element of pointers, slices, arrays and maps.
+	Elem *encoderInfo
+
+	Fields []*encoderField
+}
+
+type encoderField struct {
+	// Key is the Go string literal of the encoded key including the colon
+	Key string
+
+	// Path of the field selector including the promoted fields of embedded
+	// structs.
+	Path string
+
+	// Cond is the format of the condition to include the field with the
+	// field expression as argument. It is empty if the field is always
+	// included.
+	Cond string
+
+	// Quoted reports whether the value is encoded as a JSON string
+	Quoted bool
+
+	Info *encoderInfo
+}
+
+func GenEncoder(args []string) error {
+	fs := flag.NewFlagSet("encoder", flag.ExitOnError)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	cfg := &packages.Config{
+		Mode: packages.LoadTypes | packages.LoadAllSyntax,
+	}
+	pkgs, err := packages.Load(cfg, fs.Args()...)
+	if err != nil {
+		return err
+	}
+	if exitCode := packages.PrintErrors(pkgs); exitCode > 0 {
+		return errors.New("GenEncoder: error while loading packages")
+	}
+	for _, pkg := range pkgs {
+		for _, named := range responseModels(pkg) {
+			data := genEncoder(pkg, named)
+			var buf bytes.Buffer
+			tmpl, err := template.New("encoder.gotmpl").Funcs(FuncsMap).ParseGlob("templates/*.gotmpl")
+			if err != nil {
+				return err
+			}
+			if err := tmpl.ExecuteTemplate(&buf, "encoder", data); err != nil {
+				return err
+			}
+			formatted, err := format.Source(buf.Bytes())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stdout, "%s", formatted)
+		}
+	}
+	return nil
+}
+
+func genEncoder(pkg *packages.Package, named *types.Named) *responseModel {
+	info := resolveEncoder(named, make(map[*types.Named]bool))
+	expr := "(*r)"
+	if info.Kind == encStruct {
+		expr = "r"
+	}
+	return &responseModel{
+		PkgName:  pkg.Name,
+		Ident:    named.Obj().Name(),
+		Expr:     expr,
+		Fallible: isFallible(info),
+		Info:     info,
+	}
+}
+
+// nuagePkgPath is the import path of nuage
+const nuagePkgPath = "github.com/naivary/nuage"
+
+// responseModels returns the response models of pkg in the order of their
+// declaration. Response models are the types declared in pkg which are
+// returned by handlers i.e. functions with the signature of
+// nuage.HandlerFuncErr, or are used as type argument of nuage.HandlerFuncErr
+// and nuage.Handle.
+func responseModels(pkg *packages.Package) []*types.Named {
+	models := make([]*types.Named, 0)
+	add := func(typ types.Type) {
+		if ptr, isPtr := typ.(*types.Pointer); isPtr {
+			typ = ptr.Elem()
+		}
+		named, isNamed := typ.(*types.Named)
+		if !isNamed || named.Obj().Pkg() != pkg.Types || named.TypeArgs().Len() > 0 {
+			return
+		}
+		if _, isInterface := named.Underlying().(*types.Interface); isInterface {
+			return
+		}
+		if !slices.Contains(models, named) {
+			models = append(models, named)
+		}
+	}
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(node ast.Node) bool {
+			var sig *types.Signature
+			switch fn := node.(type) {
+			case *ast.FuncDecl:
+				sig, _ = pkg.TypesInfo.Defs[fn.Name].Type().(*types.Signature)
+			case *ast.FuncLit:
+				sig, _ = pkg.TypesInfo.TypeOf(fn).(*types.Signature)
+			}
+			if sig != nil && isHandler(sig) {
+				add(sig.Results().At(0).Type())
+			}
+			return true
+		})
+	}
+	for ident, inst := range pkg.TypesInfo.Instances {
+		obj := pkg.TypesInfo.Uses[ident]
+		if obj == nil || obj.Pkg() == nil || obj.Pkg().Path() != nuagePkgPath {
+			continue
+		}
+		if (obj.Name() == "HandlerFuncErr" || obj.Name() == "Handle") && inst.TypeArgs.Len() == 2 {
+			add(inst.TypeArgs.At(1))
+		}
+	}
+	slices.SortFunc(models, func(a, b *types.Named) int {
+		return cmp.Compare(a.Obj().Pos(), b.Obj().Pos())
+	})
+	return models
+}
+
+// isHandler reports whether sig is the signature of nuage.HandlerFuncErr.
+func isHandler(sig *types.Signature) bool {
+	params, results := sig.Params(), sig.Results()
+	if params.Len() != 2 || results.Len() != 2 {
+		return false
+	}
+	ctx, isPtr := params.At(0).Type().(*types.Pointer)
+	if !isPtr {
+		return false
+	}
+	named, isNamed := ctx.Elem().(*types.Named)
+	if !isNamed || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != nuagePkgPath || named.Obj().Name() != "Context" {
+		return false
+	}
+	return types.Identical(results.At(1).Type(), types.Universe.Lookup("error").Type())
+}
+
+// resolveEncoder returns the information needed to encode values of typ
+// following the rules of encoding/json. Values which cannot be encoded
+// without reflection e.g. interfaces or recursive types are of kind any.
+func resolveEncoder(typ types.Type, seen map[*types.Named]bool) *encoderInfo {
+	if _, isInterface := typ.Underlying().(*types.Interface); isInterface {
+		return &encoderInfo{Kind: encAny}
+	}
+	if isTime(typ) {
+		return &encoderInfo{Kind: encTime}
+	}
+	if _, isPtr := typ.(*types.Pointer); !isPtr {
+		switch {
+		case hasMethod(typ, "MarshalJSON"):
+			return &encoderInfo{Kind: encMarshaler}
+		case hasMethod(types.NewPointer(typ), "MarshalJSON"):
+			return &encoderInfo{Kind: encMarshaler, Addr: true}
+		case hasMethod(typ, "MarshalText"):
+			return &encoderInfo{Kind: encText}
+		case hasMethod(types.NewPointer(typ), "MarshalText"):
+			return &encoderInfo{Kind: encText, Addr: true}
+		}
+	}
+	if named, isNamed := typ.(*types.Named); isNamed {
+		if seen[named] {
+			return &encoderInfo{Kind: encAny}
+		}
+		seen[named] = true
+		defer delete(seen, named)
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		info := t.Info()
+		switch {
+		case info&types.IsBoolean != 0:
+			return &encoderInfo{Kind: encBool}
+		case info&types.IsString != 0:
+			return &encoderInfo{Kind: encString}
+		case info&types.IsUnsigned != 0:
+			return &encoderInfo{Kind: encUint}
+		case info&types.IsInteger != 0:
+			return &encoderInfo{Kind: encInt}
+		case info&types.IsFloat != 0:
+			return &encoderInfo{Kind: encFloat, Bits: bitSize(t.Name())}
+		}
+		return &encoderInfo{Kind: encAny}
+	case *types.Pointer:
+		return &encoderInfo{Kind: encPtr, Elem: resolveEncoder(t.Elem(), seen)}
+	case *types.Slice:
+		if isByte(t.Elem()) {
+			return &encoderInfo{Kind: encBytes}
+		}
+		return &encoderInfo{Kind: encSlice, Elem: resolveEncoder(t.Elem(), seen)}
+	case *types.Array:
+		return &encoderInfo{Kind: encArray, Elem: resolveEncoder(t.Elem(), seen)}
+	case *types.Map:
+		key, isBasic := t.Key().Underlying().(*types.Basic)
+		if !isBasic || key.Info()&types.IsString == 0 {
+			// encoding/json is sorting other keys by their encoding
+			return &encoderInfo{Kind: encAny}
+		}
+		return &encoderInfo{Kind: encMap, Elem: resolveEncoder(t.Elem(), seen)}
+	case *types.Struct:
+		fields, isSupported := resolveEncoderFields(t, seen)
+		if !isSupported {
+			return &encoderInfo{Kind: encAny}
+		}
+		return &encoderInfo{Kind: encStruct, Fields: fields}
+	default:
+		return &encoderInfo{Kind: encAny}
+	}
+}
+
+// jsonField is a field of a struct as seen by encoding/json.
+type jsonField struct {
+	// Var is the field
+	Var *types.Var
+
+	// Name is the key of the field in the JSON object
+	Name string
+
+	// Path of the field selector including the promoted fields of embedded
+	// structs.
+	Path string
+
+	// Opts are the options of the json struct tag
+	Opts []string
+
+	tagged bool
+	index  []int
+}
+
+// jsonFields returns the fields of s including the promoted fields of
+// embedded structs. Conflicting names are resolved like encoding/json.
+// Structs embedding pointers are not supported.
+func jsonFields(s *types.Struct) ([]*jsonField, bool) {
+	type embedded struct {
+		s     *types.Struct
+		path  string
+		index []int
+	}
+	candidates := make([]*jsonField, 0, s.NumFields())
+	next := []embedded{{s: s}}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		for _, e := range current {
+			for i := range e.s.NumFields() {
+				f := e.s.Field(i)
+				tag := reflect.StructTag(e.s.Tag(i)).Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				path := f.Name()
+				if e.path != "" {
+					path = e.path + "." + path
+				}
+				index := append(slices.Clone(e.index), i)
+				if f.Embedded() {
+					typ := f.Type()
+					ptr, isPtr := typ.(*types.Pointer)
+					if isPtr {
+						typ = ptr.Elem()
+					}
+					embeddedStruct, isStruct := typ.Underlying().(*types.Struct)
+					if !f.Exported() && !isStruct {
+						continue
+					}
+					if name == "" && isStruct {
+						if isPtr {
+							return nil, false
+						}
+						next = append(next, embedded{s: embeddedStruct, path: path, index: index})
+						continue
+					}
+				} else if !f.Exported() {
+					continue
+				}
+				tagged := name != ""
+				if !tagged {
+					name = f.Name()
+				}
+				candidates = append(candidates, &jsonField{
+					Var:    f,
+					Name:   name,
+					Path:   path,
+					Opts:   strings.Split(opts, ","),
+					tagged: tagged,
+					index:  index,
+				})
+			}
+		}
+	}
+	return dominantFields(candidates), true
+}
+
+// dominantFields returns the fields of the candidates which are not hidden by
+// other fields with the same name in the order of their declaration.
+func dominantFields(candidates []*jsonField) []*jsonField {
+	byName := make(map[string][]*jsonField, len(candidates))
+	for _, c := range candidates {
+		byName[c.Name] = append(byName[c.Name], c)
+	}
+	fields := make([]*jsonField, 0, len(byName))
+	for _, cs := range byName {
+		depth := len(cs[0].index)
+		for _, c := range cs {
+			depth = min(depth, len(c.index))
+		}
+		cs = slices.DeleteFunc(slices.Clone(cs), func(c *jsonField) bool {
+			return len(c.index) > depth
+		})
+		if len(cs) > 1 {
+			cs = slices.DeleteFunc(cs, func(c *jsonField) bool { return !c.tagged })
+		}
+		if len(cs) == 1 {
+			fields = append(fields, cs[0])
+		}
+	}
+	slices.SortFunc(fields, func(a, b *jsonField) int {
+		return slices.Compare(a.index, b.index)
+	})
+	return fields
+}
+
+// resolveEncoderFields returns the encoded fields of s.
+func resolveEncoderFields(s *types.Struct, seen map[*types.Named]bool) ([]*encoderField, bool) {
+	fields, isSupported := jsonFields(s)
+	if !isSupported {
+		return nil, false
+	}
+	encoderFields := make([]*encoderField, 0, len(fields))
+	for _, f := range fields {
+		cond, isSupported := includeCond(f.Var.Type(), f.Opts)
+		if !isSupported {
+			return nil, false
+		}
+		encoderFields = append(encoderFields, &encoderField{
+			Key:    strconv.Quote(jsonKey(f.Name) + ":"),
+			Path:   f.Path,
+			Cond:   cond,
+			Quoted: slices.Contains(f.Opts, "string") && isQuotable(f.Var.Type()),
+			Info:   resolveEncoder(f.Var.Type(), seen),
+		})
+	}
+	return encoderFields, true
+}
+
+// jsonKey returns the JSON string of key. It is HTML escaped like
+// encoding/json.
+func jsonKey(key string) string {
+	data, _ := json.Marshal(key)
+	return string(data)
+}
+
+// includeCond returns the format of the condition to include a field of typ
+// with the given options. False is returned if the condition cannot be
+// expressed without reflection.
+func includeCond(typ types.Type, opts []string) (string, bool) {
+	conds := make([]string, 0, 2)
+	if slices.Contains(opts, "omitempty") {
+		switch t := typ.Underlying().(type) {
+		case *types.Basic:
+			switch {
+			case t.Info()&types.IsBoolean != 0:
+				conds = append(conds, "%[1]s")
+			case t.Info()&types.IsString != 0:
+				conds = append(conds, `%[1]s != ""`)
+			case t.Info()&types.IsNumeric != 0:
+				conds = append(conds, "%[1]s != 0")
+			}
+		case *types.Slice, *types.Map, *types.Array:
+			conds = append(conds, "len(%[1]s) != 0")
+		case *types.Pointer, *types.Interface:
+			conds = append(conds, "%[1]s != nil")
+		}
+	}
+	if slices.Contains(opts, "omitzero") {
+		_, isPtr := typ.Underlying().(*types.Pointer)
+		switch {
+		case isPtr && hasMethod(typ, "IsZero"):
+			conds = append(conds, "%[1]s != nil && !%[1]s.IsZero()")
+		case hasMethod(typ, "IsZero"), hasMethod(types.NewPointer(typ), "IsZero"):
+			conds = append(conds, "!%[1]s.IsZero()")
+		case types.Comparable(typ):
+			conds = append(conds, "!jsoncodec.IsZero(%[1]s)")
+		default:
+			switch typ.Underlying().(type) {
+			case *types.Slice, *types.Map, *types.Signature:
+				conds = append(conds, "%[1]s != nil")
+			default:
+				return "", false
+			}
+		}
+	}
+	if len(conds) == 2 {
+		return "(" + conds[0] + ") && (" + conds[1] + ")", true
+	}
+	return strings.Join(conds, ""), true
+}
+
+// isQuotable reports whether the `string` option of the json tag is
+// applicable to typ.
+func isQuotable(typ types.Type) bool {
+	if ptr, isPtr := typ.(*types.Pointer); isPtr {
+		typ = ptr.Elem()
+	}
+	basic, isBasic := typ.Underlying().(*types.Basic)
+	return isBasic && basic.Info()&(types.IsBoolean|types.IsNumeric|types.IsString) != 0 &&
+		basic.Info()&types.IsComplex == 0
+}
+
+func isFallible(info *encoderInfo) bool {
+	switch info.Kind {
+	case encFloat, encTime, encMarshaler, encText, encAny:
+		return true
+	}
+	if info.Elem != nil && isFallible(info.Elem) {
+		return true
+	}
+	return slices.ContainsFunc(info.Fields, func(f *encoderField) bool {
+		return isFallible(f.Info)
+	})
+}
+
+func isTime(typ types.Type) bool {
+	named, isNamed := typ.(*types.Named)
+	if !isNamed || named.Obj().Pkg() == nil {
+		return false
+	}
+	return named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time"
+}
+
+func isByte(typ types.Type) bool {
+	basic, isBasic := typ.Underlying().(*types.Basic)
+	if !isBasic || basic.Kind() != types.Uint8 {
+		return false
+	}
+	// byte slices are only encoded as base64 if the elements are not
+	// marshalers
+	ptr := types.NewPointer(typ)
+	return !hasMethod(ptr, "MarshalJSON") && !hasMethod(ptr, "MarshalText")
+}
+
+// hasMethod reports whether the method set of typ contains the method name.
+// The signatures are not compared because the conventional methods of
+// encoding/json are expected.
+func hasMethod(typ types.Type, name string) bool {
+	sel := types.NewMethodSet(typ).Lookup(nil, name)
+	return sel != nil && sel.Obj().Exported()
+}
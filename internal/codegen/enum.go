@@ -0,0 +1,182 @@
+package codegen
+
+import (
+	"cmp"
+	"fmt"
+	"go/constant"
+	"go/types"
+	"slices"
+	"strconv"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// enum is a named type with typed constants for which the Enum method is
+// generated.
+type enum struct {
+	// Ident of the named type
+	Ident string
+
+	// Values are the identifiers of the typed constants
+	Values []string
+}
+
+// enumConsts returns the exported typed constants of named in the order of
+// their declaration if named is declared in pkg. The constants of types of
+// other packages are not considered as they may be unrelated e.g. the
+// constants of time.Duration and the Enum method cannot be generated for
+// them.
+func enumConsts(pkg *types.Package, named *types.Named) []*types.Const {
+	if named == nil || named.Obj().Pkg() != pkg {
+		return nil
+	}
+	if _, isBasic := named.Underlying().(*types.Basic); !isBasic {
+		return nil
+	}
+	scope := named.Obj().Pkg().Scope()
+	consts := make([]*types.Const, 0)
+	for _, name := range scope.Names() {
+		c, isConst := scope.Lookup(name).(*types.Const)
+		if isConst && c.Exported() && types.Identical(c.Type(), named) {
+			consts = append(consts, c)
+		}
+	}
+	slices.SortFunc(consts, func(a, b *types.Const) int {
+		return cmp.Compare(a.Pos(), b.Pos())
+	})
+	return consts
+}
+
+// enumLiterals returns the untyped Go literals of the values of consts.
+func enumLiterals(consts []*types.Const) []string {
+	literals := make([]string, 0, len(consts))
+	for _, c := range consts {
+		val := c.Val()
+		switch val.Kind() {
+		case constant.String:
+			literals = append(literals, strconv.Quote(constant.StringVal(val)))
+		case constant.Float:
+			f, _ := constant.Float64Val(val)
+			literals = append(literals, formatFloat(f))
+		default:
+			literals = append(literals, val.ExactString())
+		}
+	}
+	return literals
+}
+
+// constValues returns the values of consts as JSON values.
+func constValues(consts []*types.Const) []any {
+	values := make([]any, 0, len(consts))
+	for _, c := range consts {
+		val := c.Val()
+		switch val.Kind() {
+		case constant.String:
+			values = append(values, constant.StringVal(val))
+		case constant.Bool:
+			values = append(values, constant.BoolVal(val))
+		case constant.Int:
+			i, _ := constant.Int64Val(val)
+			values = append(values, i)
+		default:
+			f, _ := constant.Float64Val(val)
+			values = append(values, f)
+		}
+	}
+	return values
+}
+
+// enumValues returns the human readable values of consts.
+func enumValues(consts []*types.Const) []string {
+	values := make([]string, 0, len(consts))
+	for i, literal := range enumLiterals(consts) {
+		if consts[i].Val().Kind() == constant.String {
+			literal = constant.StringVal(consts[i].Val())
+		}
+		values = append(values, literal)
+	}
+	return values
+}
+
+// paramEnum returns the named type of the values of the parameter of type
+// typ if it is an enum of pkg.
+func paramEnum(pkg *types.Package, typ types.Type) *types.Named {
+	for {
+		switch t := typ.(type) {
+		case *types.Pointer:
+			typ = t.Elem()
+		case *types.Slice:
+			typ = t.Elem()
+		case *types.Named:
+			if len(enumConsts(pkg, t)) > 0 {
+				return t
+			}
+			if t.Underlying() == typ {
+				return nil
+			}
+			typ = t.Underlying()
+		default:
+			return nil
+		}
+	}
+}
+
+// genEnums adds the named types of pkg with typed constants reachable from
+// typ to r. The Enum method is generated once per type and only if it is not
+// already declared. An error is returned if the declared method is not
+// implementing nuage.Enumer.
+func genEnums(r *requestModel, pkg *packages.Package, typ types.Type, generated map[*types.Named]bool) error {
+	switch t := typ.(type) {
+	case *types.Pointer:
+		return genEnums(r, pkg, t.Elem(), generated)
+	case *types.Slice:
+		return genEnums(r, pkg, t.Elem(), generated)
+	case *types.Array:
+		return genEnums(r, pkg, t.Elem(), generated)
+	case *types.Map:
+		return genEnums(r, pkg, t.Elem(), generated)
+	case *types.Named:
+		if generated[t] {
+			return nil
+		}
+		generated[t] = true
+		consts := enumConsts(pkg.Types, t)
+		if len(consts) == 0 {
+			return genEnums(r, pkg, t.Underlying(), generated)
+		}
+		if types.NewMethodSet(types.NewPointer(t)).Lookup(pkg.Types, "Enum") != nil {
+			// the values are looked up on the zero value of the type
+			sel := types.NewMethodSet(t).Lookup(pkg.Types, "Enum")
+			if sel == nil || !isEnumMethod(sel.Obj()) {
+				return fmt.Errorf("%s: declared method Enum is not implementing nuage.Enumer", t.Obj().Name())
+			}
+			return nil
+		}
+		e := &enum{Ident: t.Obj().Name(), Values: make([]string, 0, len(consts))}
+		for _, c := range consts {
+			e.Values = append(e.Values, c.Name())
+		}
+		r.Enums = append(r.Enums, e)
+	case *types.Struct:
+		for i := range t.NumFields() {
+			if err := genEnums(r, pkg, t.Field(i).Type(), generated); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isEnumMethod reports whether obj is a method implementing nuage.Enumer.
+func isEnumMethod(obj types.Object) bool {
+	fn, isFunc := obj.(*types.Func)
+	if !isFunc {
+		return false
+	}
+	sig := fn.Signature()
+	if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return false
+	}
+	anys := types.NewSlice(types.NewInterfaceType(nil, nil))
+	return types.Identical(sig.Results().At(0).Type(), anys)
+}
@@ -49,8 +49,8 @@ func isSupportedCookieParamType(typ types.Type) bool {
 	if !isNamed {
 		return false
 	}
-	fqpn := fmt.Sprintf("%s.%s", named.Obj().Pkg().Name(), named.Obj().Name())
-	return fqpn == "http.Cookie"
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "net/http" && obj.Name() == "Cookie"
 }
 
 func isSupportedHeaderParamType(typ types.Type) bool {
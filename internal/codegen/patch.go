@@ -0,0 +1,220 @@
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/types"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// kinds of fields in the generated patch types
+const (
+	// patchReplace replaces the value of the field
+	patchReplace = "replace"
+
+	// patchStruct merges the patch into the struct
+	patchStruct = "struct"
+
+	// patchPtr merges the patch into the struct the pointer is pointing to
+	patchPtr = "ptr"
+
+	// patchMap merges the members of the patch into the map
+	patchMap = "map"
+)
+
+type patchModel struct {
+	// Package in which the resources were found
+	PkgName string
+
+	// Import paths of the types of the fields
+	Imports []string
+
+	Types []*patchType
+}
+
+type patchType struct {
+	// Identifier of the patch type
+	Ident string
+
+	// Identifier of the patched resource
+	Resource string
+
+	Fields []*patchField
+}
+
+type patchField struct {
+	// Identifier of the field in the patch type
+	Ident string
+
+	// Key is the JSON name of the member
+	Key string
+
+	// Type of the field in the patch type. Fields using the `string` option
+	// of encoding/json are of type mergepatch.QuotedField.
+	Type string
+
+	// Path of the field selector in the resource including the promoted
+	// fields of embedded structs.
+	Path string
+
+	Kind string
+
+	// Elem is the identifier of the patched struct
+	Elem string
+}
+
+// GenPatch generates the JSON Merge Patch type of the resources given by the
+// `-type` flag. Structs of the same package are patched recursively and get a
+// patch type as well.
+func GenPatch(args []string) error {
+	fs := flag.NewFlagSet("patch", flag.ExitOnError)
+	typeNames := fs.String("type", "", "comma-separated list of resources")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *typeNames == "" {
+		return errors.New("GenPatch: -type is required")
+	}
+	cfg := &packages.Config{
+		Mode: packages.LoadTypes | packages.LoadAllSyntax,
+	}
+	pkgs, err := packages.Load(cfg, fs.Args()...)
+	if err != nil {
+		return err
+	}
+	if exitCode := packages.PrintErrors(pkgs); exitCode > 0 {
+		return errors.New("GenPatch: error while loading packages")
+	}
+	for _, pkg := range pkgs {
+		data, err := genPatch(pkg, strings.Split(*typeNames, ","))
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		tmpl, err := template.New("patch.gotmpl").Funcs(FuncsMap).ParseGlob("templates/*.gotmpl")
+		if err != nil {
+			return err
+		}
+		if err := tmpl.ExecuteTemplate(&buf, "patch", data); err != nil {
+			return err
+		}
+		formatted, err := format.Source(buf.Bytes())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "%s", formatted)
+	}
+	return nil
+}
+
+func genPatch(pkg *packages.Package, typeNames []string) (*patchModel, error) {
+	p := patchModel{
+		PkgName: pkg.Name,
+		Imports: make([]string, 0),
+	}
+	qualifier := func(other *types.Package) string {
+		if other == pkg.Types {
+			return ""
+		}
+		p.Imports = append(p.Imports, other.Path())
+		return other.Name()
+	}
+	queue := make([]*types.Named, 0, len(typeNames))
+	for _, name := range typeNames {
+		obj, isTypeName := pkg.Types.Scope().Lookup(name).(*types.TypeName)
+		if !isTypeName {
+			return nil, fmt.Errorf("type not found: %s", name)
+		}
+		named, isNamed := obj.Type().(*types.Named)
+		if !isNamed || !isPatchable(pkg, named) {
+			return nil, fmt.Errorf("type is not a struct: %s", name)
+		}
+		queue = append(queue, named)
+	}
+	generated := make(map[*types.Named]bool, len(queue))
+	for len(queue) > 0 {
+		named := queue[0]
+		queue = queue[1:]
+		if generated[named] {
+			continue
+		}
+		generated[named] = true
+		fields, isSupported := jsonFields(named.Underlying().(*types.Struct))
+		if !isSupported {
+			return nil, fmt.Errorf("embedded pointers are not supported: %s", named.Obj().Name())
+		}
+		t := &patchType{
+			Ident:    named.Obj().Name() + "Patch",
+			Resource: named.Obj().Name(),
+			Fields:   make([]*patchField, 0, len(fields)),
+		}
+		for _, f := range fields {
+			field := &patchField{
+				Ident: f.Var.Name(),
+				Key:   f.Name,
+				Path:  f.Path,
+				Kind:  patchReplace,
+			}
+			if slices.ContainsFunc(t.Fields, func(other *patchField) bool { return other.Ident == field.Ident }) {
+				return nil, fmt.Errorf("%s: conflicting field %s", t.Resource, field.Ident)
+			}
+			typ := f.Var.Type()
+			value := types.TypeString(typ, qualifier)
+			switch u := typ.Underlying().(type) {
+			case *types.Struct:
+				if named, isNamed := typ.(*types.Named); isNamed && isPatchable(pkg, named) {
+					field.Kind, value = patchStruct, named.Obj().Name()+"Patch"
+					field.Elem = named.Obj().Name()
+					queue = append(queue, named)
+				}
+			case *types.Pointer:
+				if named, isNamed := u.Elem().(*types.Named); isNamed && isPatchable(pkg, named) {
+					field.Kind, value = patchPtr, named.Obj().Name()+"Patch"
+					field.Elem = named.Obj().Name()
+					queue = append(queue, named)
+				}
+			case *types.Map:
+				key, isBasic := u.Key().Underlying().(*types.Basic)
+				if isBasic && key.Info()&types.IsString != 0 {
+					field.Kind = patchMap
+					value = fmt.Sprintf("map[%s]mergepatch.Field[%s]",
+						types.TypeString(u.Key(), qualifier),
+						types.TypeString(u.Elem(), qualifier),
+					)
+				}
+			}
+			field.Type = fmt.Sprintf("mergepatch.Field[%s]", value)
+			if slices.Contains(f.Opts, "string") && isQuotable(typ) {
+				// the value is encoded as a JSON string
+				field.Type = fmt.Sprintf("mergepatch.QuotedField[%s]", value)
+			}
+			t.Fields = append(t.Fields, field)
+		}
+		p.Types = append(p.Types, t)
+	}
+	slices.Sort(p.Imports)
+	p.Imports = slices.Compact(p.Imports)
+	return &p, nil
+}
+
+// isPatchable reports whether named is a struct of pkg which is merged
+// recursively. Structs decoding themselves are replaced as a whole.
+func isPatchable(pkg *packages.Package, named *types.Named) bool {
+	if named.Obj().Pkg() != pkg.Types || named.TypeArgs().Len() > 0 {
+		return false
+	}
+	if _, isStruct := named.Underlying().(*types.Struct); !isStruct {
+		return false
+	}
+	ptr := types.NewPointer(named)
+	return !hasMethod(ptr, "UnmarshalJSON") && !hasMethod(ptr, "UnmarshalText")
+}
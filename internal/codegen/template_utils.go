@@ -3,6 +3,7 @@ package codegen
 import (
 	"slices"
 	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/naivary/nuage/openapi"
@@ -16,6 +17,8 @@ var FuncsMap = template.FuncMap{
 	"IsInteger":           isInteger,
 	"ElemType":            elemType,
 	"IsQueryParamDefined": isQueryParamDefined,
+	"Inc":                 inc,
+	"Join":                strings.Join,
 }
 
 func bitSize(typ string) int {
@@ -67,7 +70,7 @@ func isInteger(kind string) bool {
 	switch kind {
 	case "int", "int8", "int16", "int32", "int64":
 		return true
-	case "uint", "uin8", "uin16", "uin32", "uin64":
+	case "uint", "uint8", "uint16", "uint32", "uint64":
 		return true
 	default:
 		return false
@@ -94,3 +97,7 @@ func isQueryParamDefined(params []*parameter) bool {
 		return p.In == openapi.ParamInQuery
 	})
 }
+
+func inc(i int) int {
+	return i + 1
+}
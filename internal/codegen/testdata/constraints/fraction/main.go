@@ -0,0 +1,5 @@
+package main
+
+type ListRequest struct {
+	Limit int64 `query:"limit,max=10.5"`
+}
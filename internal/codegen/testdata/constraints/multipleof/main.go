@@ -0,0 +1,5 @@
+package main
+
+type ListRequest struct {
+	Offset int8 `query:"offset,multipleOf=200"`
+}
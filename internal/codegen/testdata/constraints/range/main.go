@@ -0,0 +1,5 @@
+package main
+
+type ListRequest struct {
+	Page uint16 `query:"page,min=-1"`
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/naivary/nuage/jsoncodec"
+)
+
+func TestDecode(t *testing.T) {
+	body := `{
+		"createdAt": "2024-01-02T03:04:05.000000006Z",
+		"name": "jane",
+		"age": 42,
+		"score": "1.5",
+		"tags": ["a", "b"],
+		"labels": {"x": "y"},
+		"addresses": [{"street": "first", "city": "berlin"}, null],
+		"avatar": "YXZhdGFy",
+		"extra": {"nested": [1, "two", null]},
+		"matrix": [[1, 2], [3, 4]],
+		"home": {"street": "home"},
+		"work": {"street": "work", "city": "paris"},
+		"status": "active",
+		"priority": "2",
+		"friends": [{"name": "john", "status": "inactive"}],
+		"timeout": 5000000000,
+		"sameSite": 3
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/tenants/acme/users", strings.NewReader(body))
+	req.SetPathValue("tenant", "acme")
+	var got CreateUserRequest
+	if err := got.Decode(req); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var want User
+	if err := json.Unmarshal([]byte(body), &want); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.Tenant != "acme" || !reflect.DeepEqual(got.User, want) {
+		t.Errorf("got %+v want %+v", got.User, want)
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	tests := []string{
+		`{"status": "deleted"}`,
+		`{"status": "unknown"}`,
+		`{"priority": "3"}`,
+		`{"unknown": 1}`,
+		`{"friends": [{"friends": [{"friends": [{"friends": [{"friends": []}]}]}]}]}`,
+	}
+	for _, body := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/tenants/acme/users", strings.NewReader(body))
+		var r CreateUserRequest
+		if err := r.Decode(req); err == nil {
+			t.Errorf("%s: decoded", body)
+		}
+	}
+}
+
+func TestDecode_Recursive(t *testing.T) {
+	body := `{
+		"name": "jane",
+		"status": "active",
+		"manager": {"name": "jim", "manager": {"name": "joe", "reports": []}},
+		"reports": [{"name": "john", "status": "inactive", "groups": null}],
+		"groups": {"admins": {"root": {}}, "users": null}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/members", strings.NewReader(body))
+	var got CreateMemberRequest
+	if err := got.Decode(req); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var want Member
+	if err := json.Unmarshal([]byte(body), &want); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(got.Member, want) {
+		t.Errorf("got %+v want %+v", got.Member, want)
+	}
+}
+
+func TestDecode_RecursiveInvalid(t *testing.T) {
+	// values of recursive types are decoded by the generated functions
+	// checking enums, unknown members and the depth at every level
+	tests := map[string]string{
+		`{"reports": [{"status": "deleted"}]}`:            "/reports/0/status",
+		`{"manager": {"manager": {"status": "deleted"}}}`: "/manager/manager/status",
+		`{"reports": [{}, {"unknown": 1}]}`:               "/reports/1/unknown",
+		`{"manager": {"groups": {"admins": []}}}`:         "/manager/groups/admins",
+		`{"manager": {"manager": {"manager": {"manager": {"manager": {"manager": {"manager": {"manager": {}}}}}}}}}`: "/manager/manager/manager/manager/manager/manager/manager/manager",
+		`{"groups": {"a": {"b": {"c": {"d": {"e": {"f": {"g": {}}}}}}}}}`:                                            "/groups/a/b/c/d/e/f/g",
+	}
+	for body, pointer := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/members", strings.NewReader(body))
+		var r CreateMemberRequest
+		err := r.Decode(req)
+		var decodeErr *jsoncodec.DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Errorf("%s: expected a decode error: %v", body, err)
+			continue
+		}
+		if decodeErr.Pointer != pointer {
+			t.Errorf("%s: pointer: got %s want %s", body, decodeErr.Pointer, pointer)
+		}
+	}
+}
+
+func TestDecode_Params(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?slice_string=a,b&slice_int=1&slice_int=2&boolean=true", nil)
+	for name, value := range map[string]string{
+		"str":                 "s",
+		"int":                 "1",
+		"int_32":              "32",
+		"ptr_int32":           "-32",
+		"ptr_named_ptr_int32": "64",
+	} {
+		req.SetPathValue(name, value)
+	}
+	var path PathParamRequest
+	if err := path.Decode(req); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if path.Str != "s" || path.Int != 1 || path.Int32 != 32 || *path.PtrI32 != -32 || **path.PtrNamedPtrInt32 != 64 {
+		t.Errorf("path parameters decoded wrongly: %+v", path)
+	}
+	var query QueryParamRequest
+	if err := query.Decode(req); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !query.Bool || !reflect.DeepEqual(query.SliceString, []string{"a", "b"}) || !reflect.DeepEqual(query.SliceInt, []int{1, 2}) {
+		t.Errorf("query parameters decoded wrongly: %+v", query)
+	}
+}
+
+func TestDecode_Constraints(t *testing.T) {
+	tests := []struct {
+		query   string
+		isValid bool
+	}{
+		{query: "?cursor=9007199254740992&level=-128", isValid: true},
+		// not distinguishable from the maximum as float64
+		{query: "?cursor=9007199254740993"},
+		{query: "?level=3"},
+		{query: "?limit=7"},
+		{query: "?limit=105"},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/users/1"+tc.query, nil)
+		req.SetPathValue("id", "1")
+		req.Header.Set("Tenant", "acme")
+		var r ConstrainedParamRequest
+		err := r.Decode(req)
+		if tc.isValid && err != nil {
+			t.Errorf("%s: %v", tc.query, err)
+		}
+		if !tc.isValid && err == nil {
+			t.Errorf("%s: decoded", tc.query)
+		}
+	}
+}
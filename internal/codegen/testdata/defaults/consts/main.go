@@ -0,0 +1,12 @@
+package main
+
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusInactive Status = "inactive"
+)
+
+type ListRequest struct {
+	Status Status `query:"status,default=deleted"`
+}
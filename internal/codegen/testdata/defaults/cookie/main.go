@@ -0,0 +1,5 @@
+package main
+
+type ListRequest struct {
+	Session string `cookie:"session,default=anonymous"`
+}
@@ -0,0 +1,7 @@
+package main
+
+import "net/http"
+
+type ListRequest struct {
+	Session *http.Cookie `cookie:"session,default=anonymous,pattern=^[a-f0-9]{32}$"`
+}
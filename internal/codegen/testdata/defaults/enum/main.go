@@ -0,0 +1,5 @@
+package main
+
+type ListRequest struct {
+	Order string `query:"order,default=random,enum=asc|desc"`
+}
@@ -0,0 +1,5 @@
+package main
+
+type ListRequest struct {
+	Tags []string `query:"tags,default=a|b|c,maxItems=2"`
+}
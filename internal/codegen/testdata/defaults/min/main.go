@@ -0,0 +1,5 @@
+package main
+
+type ListRequest struct {
+	Limit int `query:"limit,default=0,min=1"`
+}
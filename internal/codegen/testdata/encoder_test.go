@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/naivary/nuage"
+)
+
+func TestEncodeJSON(t *testing.T) {
+	nickname := "jd"
+	deletedAt := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	user := UserResponse{
+		Audit:     Audit{CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC), DeletedAt: &deletedAt},
+		ID:        42,
+		Name:      "jane \"doe\"\n",
+		Nickname:  &nickname,
+		Score:     1.5,
+		Ratio:     0.25,
+		Active:    true,
+		Tags:      []string{"a", "b"},
+		Labels:    map[String]Int32{"x": 1, "y": -2},
+		Address:   Address{Street: "main"},
+		Addresses: []*Address{{Street: "first", City: "berlin"}, nil},
+		Avatar:    []byte("avatar"),
+		Extra:     map[string]any{"nested": []any{1, "two"}},
+		Raw:       json.RawMessage(`{"raw":true}`),
+		Matrix:    [2][2]int{{1, 2}, {3, 4}},
+		Internal:  "internal",
+		Untagged:  7,
+	}
+	models := []nuage.JSONEncoder{
+		&user,
+		&UserResponse{},
+		&UsersResponse{user, {}},
+		&UsersResponse{},
+		&CountsResponse{"a": 1, "b": 2},
+		&Profile{Name: "jane", Since: user.CreatedAt},
+	}
+	for _, model := range models {
+		var got bytes.Buffer
+		if err := model.EncodeJSON(&got); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		want, err := json.Marshal(model)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if !bytes.Equal(bytes.TrimSpace(got.Bytes()), want) {
+			t.Errorf("%T:\ngot  %s\nwant %s", model, got.Bytes(), want)
+		}
+	}
+}
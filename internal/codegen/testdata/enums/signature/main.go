@@ -0,0 +1,14 @@
+package main
+
+type Order string
+
+const (
+	OrderAsc  Order = "asc"
+	OrderDesc Order = "desc"
+)
+
+func (Order) Enum() []string { return []string{"asc", "desc"} }
+
+type ListRequest struct {
+	Order Order `query:"order"`
+}
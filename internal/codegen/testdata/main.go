@@ -1,6 +1,12 @@
 package main
 
-import "net/http"
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/naivary/nuage"
+)
 
 type (
 	String    string
@@ -40,6 +46,65 @@ type QueryParamRequest struct {
 	MapNotExplode map[string]string `query:"mapper,explode=false"`
 }
 
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusInactive Status = "inactive"
+
+	// statusUnknown is unexported and not a value of the enum
+	statusUnknown Status = "unknown"
+)
+
+type Priority int
+
+const (
+	PriorityLow Priority = iota + 1
+	PriorityHigh
+)
+
+// Role declares its Enum method which is not generated again
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+)
+
+func (Role) Enum() []any { return []any{RoleAdmin, RoleMember} }
+
+type EnumParamRequest struct {
+	Status   Status    `query:"status"`
+	Statuses []Status  `query:"statuses"`
+	Priority *Priority `path:"priority"`
+	Role     Role      `query:"role"`
+}
+
+type RequiredParamRequest struct {
+	Cursor  string       `query:"cursor,required"`
+	Tenant  *string      `header:"Tenant,required"`
+	Session *http.Cookie `cookie:"session,required"`
+}
+
+type ListUsersRequest struct {
+	Limit   *int32       `query:"limit,default=20,min=1,max=100"`
+	Offset  uint64       `query:"offset,default=0"`
+	Status  Status       `query:"status,default=active"`
+	Fields  []string     `query:"fields,default=id|name"`
+	Lang    string       `header:"Lang,default=en"`
+	Session *http.Cookie `cookie:"session,default=anonymous"`
+}
+
+type ConstrainedParamRequest struct {
+	ID     int64    `path:"id,min=1"`
+	Limit  *int32   `query:"limit,min=1,max=100,multipleOf=5"`
+	Order  string   `query:"order,enum=asc|desc"`
+	Tags   []string `query:"tags,minItems=1,maxItems=5,maxLength=16,pattern=^[a-z]{1,16}$"`
+	Tenant string   `header:"Tenant,minLength=3"`
+	Cursor uint64   `query:"cursor,max=9007199254740992"`
+	Level  int8     `query:"level,min=-128,max=127,multipleOf=2"`
+}
+
 type CookieParamRequest struct {
 	CPtr *http.Cookie `cookie:"x_ptr"`
 }
@@ -47,3 +112,108 @@ type CookieParamRequest struct {
 type HeaderParamRequest struct {
 	Str string `header:"str"`
 }
+
+type Address struct {
+	Street string `json:"street"`
+	City   string `json:"city,omitempty"`
+}
+
+type Audit struct {
+	CreatedAt time.Time  `json:"createdAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitzero"`
+}
+
+type UserResponse struct {
+	Audit
+	ID        uint64           `json:"id,string"`
+	Name      string           `json:"name"`
+	Nickname  *string          `json:"nickname"`
+	Score     float64          `json:"score"`
+	Ratio     float32          `json:"ratio,omitempty"`
+	Active    bool             `json:"active"`
+	Tags      []string         `json:"tags"`
+	Labels    map[String]Int32 `json:"labels,omitempty"`
+	Address   Address          `json:"address"`
+	Addresses []*Address       `json:"addresses"`
+	Avatar    []byte           `json:"avatar,omitempty"`
+	Extra     any              `json:"extra"`
+	Raw       json.RawMessage  `json:"raw,omitempty"`
+	Matrix    [2][2]int        `json:"matrix"`
+	Internal  string           `json:"-"`
+	Untagged  int
+	private   string
+}
+
+type UsersResponse []UserResponse
+
+type CountsResponse map[string]int
+
+// Profile is a response model without the Response suffix
+type Profile struct {
+	Name     string    `json:"name"`
+	Nickname *string   `json:"nickname,omitempty"`
+	Since    time.Time `json:"since"`
+}
+
+// UnusedResponse is not returned by any handler
+type UnusedResponse struct {
+	Name string `json:"name"`
+}
+
+func getUser(ctx *nuage.Context, r *HeaderParamRequest) (*UserResponse, error) {
+	return &UserResponse{}, nil
+}
+
+func listUsers(ctx *nuage.Context, r *ListUsersRequest) (UsersResponse, error) {
+	return UsersResponse{}, nil
+}
+
+var countUsers = func(ctx *nuage.Context, r *EnumParamRequest) (CountsResponse, error) {
+	return CountsResponse{}, nil
+}
+
+func getProfile(ctx *nuage.Context, r *CookieParamRequest) (Profile, error) {
+	return Profile{}, nil
+}
+
+type User struct {
+	Audit
+	Name      string            `json:"name"`
+	Age       *uint8            `json:"age"`
+	Score     float32           `json:"score,string"`
+	Tags      []string          `json:"tags"`
+	Labels    map[string]String `json:"labels"`
+	Addresses []*Address        `json:"addresses"`
+	Avatar    []byte            `json:"avatar"`
+	Extra     any               `json:"extra"`
+	Matrix    [2][2]int         `json:"matrix"`
+	Home      *Address          `json:"home"`
+	Work      Address           `json:"work"`
+	Status    Status            `json:"status"`
+	Priority  *Priority         `json:"priority,string"`
+	Friends   []User            `json:"friends"`
+	Timeout   time.Duration     `json:"timeout"`
+	SameSite  http.SameSite     `json:"sameSite"`
+	Internal  string            `json:"-"`
+}
+
+// Member is a recursive type referring to itself through a pointer
+type Member struct {
+	Name    string   `json:"name"`
+	Status  Status   `json:"status"`
+	Manager *Member  `json:"manager"`
+	Reports []Member `json:"reports"`
+	Groups  Groups   `json:"groups"`
+}
+
+// Groups is a recursive named map type
+type Groups map[string]Groups
+
+type CreateMemberRequest struct {
+	Member Member `body:"strict,depth=8"`
+}
+
+type CreateUserRequest struct {
+	Tenant string `path:"tenant"`
+	User   User   `body:"strict,depth=8"`
+}
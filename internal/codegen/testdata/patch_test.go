@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/naivary/nuage/mergepatch"
+)
+
+func TestApplyTo(t *testing.T) {
+	target := func() User {
+		return User{
+			Name:   "jane",
+			Tags:   []string{"a"},
+			Labels: map[string]String{"a": "x", "b": "y"},
+			Home:   &Address{Street: "home"},
+			Status: StatusActive,
+			Score:  1.5,
+		}
+	}
+	patch := []byte(`{
+		"name": "john",
+		"tags": null,
+		"labels": {"a": null, "c": "z"},
+		"home": {"city": "berlin"},
+		"work": {"street": "work"},
+		"status": "inactive",
+		"score": "2.5",
+		"priority": "2"
+	}`)
+	var p UserPatch
+	if err := json.Unmarshal(patch, &p); err != nil {
+		t.Fatalf("unmarshal patch: %v", err)
+	}
+	got := target()
+	p.ApplyTo(&got)
+
+	data, err := json.Marshal(target())
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	merged, err := mergepatch.Apply(data, patch)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	var want User
+	if err := json.Unmarshal(merged, &want); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+	if got.Score != 2.5 || got.Priority == nil || *got.Priority != PriorityHigh {
+		t.Errorf("quoted members are not applied: %+v", got)
+	}
+	// the quoted members are encoded as JSON strings again
+	data, err = json.Marshal(p)
+	if err != nil {
+		t.Fatalf("marshal patch: %v", err)
+	}
+	if !strings.Contains(string(data), `"score":"2.5"`) || !strings.Contains(string(data), `"priority":"2"`) {
+		t.Errorf("quoted members are not quoted: %s", data)
+	}
+}
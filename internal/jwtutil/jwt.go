@@ -0,0 +1,143 @@
+package jwtutil
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrMalformed            = errors.New("token is malformed")
+	ErrAlgorithmUnsupported = errors.New("signing algorithm is not supported")
+	ErrSignatureInvalid     = errors.New("signature is invalid")
+)
+
+// Header is the JOSE header of a JSON Web Signature as defined in RFC 7515.
+type Header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Token is a parsed but not yet verified JSON Web Token in the compact
+// serialization as defined in RFC 7519.
+type Token struct {
+	Header Header
+	Claims map[string]any
+
+	// signingInput is the part of the token covered by the signature.
+	signingInput string
+	signature    []byte
+}
+
+// Parse parses the compact serialization of a JSON Web Token without
+// verifying its signature.
+func Parse(raw string) (*Token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrMalformed, err)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrMalformed, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrMalformed, err)
+	}
+	t := Token{
+		signingInput: parts[0] + "." + parts[1],
+		signature:    signature,
+	}
+	if err := json.Unmarshal(header, &t.Header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrMalformed, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&t.Claims); err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrMalformed, err)
+	}
+	return &t, nil
+}
+
+// Verify verifies the signature of t using key. The algorithm defined in the
+// header of t has to match the type of key.
+func (t *Token) Verify(key crypto.PublicKey) error {
+	switch t.Header.Alg {
+	case "RS256", "RS384", "RS512":
+		pub, isRSA := key.(*rsa.PublicKey)
+		if !isRSA {
+			return ErrSignatureInvalid
+		}
+		hash, digest := t.digest()
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, t.signature); err != nil {
+			return ErrSignatureInvalid
+		}
+	case "PS256", "PS384", "PS512":
+		pub, isRSA := key.(*rsa.PublicKey)
+		if !isRSA {
+			return ErrSignatureInvalid
+		}
+		hash, digest := t.digest()
+		if err := rsa.VerifyPSS(pub, hash, digest, t.signature, nil); err != nil {
+			return ErrSignatureInvalid
+		}
+	case "ES256", "ES384", "ES512":
+		pub, isECDSA := key.(*ecdsa.PublicKey)
+		if !isECDSA {
+			return ErrSignatureInvalid
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(t.signature) != 2*size {
+			return ErrSignatureInvalid
+		}
+		r := new(big.Int).SetBytes(t.signature[:size])
+		s := new(big.Int).SetBytes(t.signature[size:])
+		_, digest := t.digest()
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return ErrSignatureInvalid
+		}
+	case "EdDSA":
+		pub, isEd25519 := key.(ed25519.PublicKey)
+		if !isEd25519 {
+			return ErrSignatureInvalid
+		}
+		if !ed25519.Verify(pub, []byte(t.signingInput), t.signature) {
+			return ErrSignatureInvalid
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrAlgorithmUnsupported, t.Header.Alg)
+	}
+	return nil
+}
+
+// digest returns the hash function defined by the algorithm of t and the
+// digest of the signing input.
+func (t *Token) digest() (crypto.Hash, []byte) {
+	var hash crypto.Hash
+	switch t.Header.Alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	default:
+		hash = crypto.SHA512
+	}
+	h := hash.New()
+	h.Write([]byte(t.signingInput))
+	return hash, h.Sum(nil)
+}
@@ -0,0 +1,72 @@
+package openapiutil
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/naivary/nuage/jsoncodec"
+)
+
+// BodyTag is the struct tag marking the field of a request model into which
+// the request body is decoded e.g. `body:"strict,depth=16"`.
+const BodyTag = "body"
+
+type BodyOpts struct {
+	// Strict rejects unknown object members
+	Strict bool
+
+	// MaxDepth is the maximum nesting depth of objects and arrays
+	MaxDepth int
+}
+
+// IsBody reports whether the field with the given tag is the body of the
+// request model.
+func IsBody(tag reflect.StructTag) bool {
+	_, isBody := tag.Lookup(BodyTag)
+	return isBody
+}
+
+func ParseBodyOpts(tag reflect.StructTag) (*BodyOpts, error) {
+	value, isBody := tag.Lookup(BodyTag)
+	if !isBody {
+		return nil, nil
+	}
+	opts := &BodyOpts{MaxDepth: jsoncodec.DefaultMaxDepth}
+	if value == "" {
+		return opts, nil
+	}
+	for opt := range strings.SplitSeq(value, ",") {
+		key, rhs, _ := strings.Cut(opt, "=")
+		switch key {
+		case "strict":
+			opts.Strict = true
+		case "depth":
+			depth, err := strconv.Atoi(rhs)
+			if err != nil || depth <= 0 {
+				return nil, fmt.Errorf("depth must be a positive integer: %s", opt)
+			}
+			opts.MaxDepth = depth
+		default:
+			return nil, fmt.Errorf("unknown body option: %s", opt)
+		}
+	}
+	return opts, nil
+}
+
+// BodyField returns the field of the struct typ marked as body.
+func BodyField(typ reflect.Type) (reflect.StructField, bool) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	for i := range typ.NumField() {
+		if field := typ.Field(i); IsBody(field.Tag) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -1,13 +1,17 @@
 package openapiutil
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/naivary/nuage/openapi"
 )
 
@@ -74,6 +78,9 @@ func defaultParamOpts(name string, in openapi.ParamIn) *ParamOpts {
 	}
 }
 
+// ParamOpts are the options of a parameter defined in its struct tag e.g.
+// `query:"limit,min=1,max=100"`. The constraints are documented in the schema
+// of the parameter and enforced by the generated decoders.
 type ParamOpts struct {
 	In           openapi.ParamIn
 	Name         string
@@ -82,6 +89,38 @@ type ParamOpts struct {
 	Explode      bool
 	IsDeprecated bool
 	Default      any
+
+	// Minimum and Maximum are the inclusive bounds of numbers
+	Minimum *float64
+	Maximum *float64
+
+	// MultipleOf is the number by which numbers must be divisible
+	MultipleOf *float64
+
+	// MinLength and MaxLength are the bounds of the length of strings in
+	// unicode code points.
+	MinLength *int
+	MaxLength *int
+
+	// Pattern is the regular expression strings must match. It has to be
+	// the last option because the expression may contain commas.
+	Pattern string
+
+	// Enum are the allowed values separated by `|` in the struct tag
+	Enum []string
+
+	// MinItems and MaxItems are the bounds of the number of values of
+	// arrays.
+	MinItems *int
+	MaxItems *int
+}
+
+// HasConstraints reports whether any constraint is defined for the value of
+// the parameter.
+func (p *ParamOpts) HasConstraints() bool {
+	return p.Minimum != nil || p.Maximum != nil || p.MultipleOf != nil ||
+		p.MinLength != nil || p.MaxLength != nil || p.Pattern != "" ||
+		len(p.Enum) > 0 || p.MinItems != nil || p.MaxItems != nil
 }
 
 func ParseParamOpts(tag reflect.StructTag) (*ParamOpts, error) {
@@ -94,19 +133,29 @@ func ParseParamOpts(tag reflect.StructTag) (*ParamOpts, error) {
 	if len(tagValue) == 0 {
 		return nil, errors.New("parameter tag value is empty")
 	}
+	tagValue, pattern, hasPattern := strings.Cut(tagValue, ",pattern=")
 	definedOpts := strings.Split(tagValue, ",")
 	opts := defaultParamOpts(definedOpts[0], in)
+	if hasPattern {
+		if pattern == "" {
+			return nil, errors.New("rhs of `pattern` is empty")
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("pattern: %w", err)
+		}
+		opts.Pattern = pattern
+	}
 	// per default all parameters are required and become
 	// optional when a default is set.
 	for _, opt := range definedOpts[1:] {
-		if opt == "deprecated" {
+		key, value, _ := strings.Cut(opt, "=")
+		var err error
+		switch key {
+		case "deprecated":
 			opts.IsDeprecated = true
-		}
-		if opt == "required" {
+		case "required":
 			opts.Required = true
-		}
-		if strings.HasPrefix(opt, "explode") {
-			_, value, _ := strings.Cut(opt, "=")
+		case "explode":
 			if value == "" {
 				return nil, fmt.Errorf("rhs of `%s` is empty", opt)
 			}
@@ -115,20 +164,250 @@ func ParseParamOpts(tag reflect.StructTag) (*ParamOpts, error) {
 				return nil, err
 			}
 			opts.Explode = e
-		}
-		if strings.HasPrefix(opt, "style") {
-			_, value, _ := strings.Cut(opt, "=")
+		case "style":
 			opts.Style = openapi.ParamStyle(value)
+		case "default":
+			if in != openapi.ParamInPath {
+				opts.Default = any(value)
+				opts.Required = false
+			}
+		case "min":
+			opts.Minimum, err = parseNumberOpt(opt, value)
+		case "max":
+			opts.Maximum, err = parseNumberOpt(opt, value)
+		case "multipleOf":
+			opts.MultipleOf, err = parseNumberOpt(opt, value)
+			if err == nil && *opts.MultipleOf <= 0 {
+				err = fmt.Errorf("multipleOf must be greater than zero: %s", opt)
+			}
+		case "minLength":
+			opts.MinLength, err = parseCountOpt(opt, value)
+		case "maxLength":
+			opts.MaxLength, err = parseCountOpt(opt, value)
+		case "minItems":
+			opts.MinItems, err = parseCountOpt(opt, value)
+		case "maxItems":
+			opts.MaxItems, err = parseCountOpt(opt, value)
+		case "enum":
+			if value == "" {
+				return nil, fmt.Errorf("rhs of `%s` is empty", opt)
+			}
+			opts.Enum = strings.Split(value, "|")
 		}
-		if strings.HasPrefix(opt, "default") && in != openapi.ParamInPath {
-			_, value, _ := strings.Cut(opt, "=")
-			opts.Default = any(value)
-			opts.Required = false
+		if err != nil {
+			return nil, err
 		}
 	}
+	if isGreater(opts.Minimum, opts.Maximum) {
+		return nil, errors.New("min is greater than max")
+	}
+	if isGreater(opts.MinLength, opts.MaxLength) {
+		return nil, errors.New("minLength is greater than maxLength")
+	}
+	if isGreater(opts.MinItems, opts.MaxItems) {
+		return nil, errors.New("minItems is greater than maxItems")
+	}
 	return opts, nil
 }
 
+func parseNumberOpt(opt, value string) (*float64, error) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("rhs must be a number: %s", opt)
+	}
+	return &n, nil
+}
+
+func parseCountOpt(opt, value string) (*int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("rhs must be a non-negative integer: %s", opt)
+	}
+	return &n, nil
+}
+
+func isGreater[T int | float64](lhs, rhs *T) bool {
+	return lhs != nil && rhs != nil && *lhs > *rhs
+}
+
+// ParamSchema returns the schema of the parameter of type typ including the
+// constraints and the default value defined by opts.
+func ParamSchema(typ reflect.Type, opts *ParamOpts) (*jsonschema.Schema, error) {
+	s, err := paramSchema(typ, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Default == nil {
+		return s, nil
+	}
+	def, err := ParamDefault(s, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.Default, err = json.Marshal(def)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ParamDefault returns the default value of opts converted to the type of the
+// schema s of the parameter. The values of arrays are separated by `|`. The
+// default has to satisfy the constraints of s.
+func ParamDefault(s *jsonschema.Schema, opts *ParamOpts) (any, error) {
+	value, isString := opts.Default.(string)
+	if !isString {
+		return nil, fmt.Errorf("%s: default must be a string: %v", opts.Name, opts.Default)
+	}
+	var def any
+	switch s.Type {
+	case "object":
+		return nil, fmt.Errorf("%s: default is not supported by objects", opts.Name)
+	case "array":
+		values := make([]any, 0, 1)
+		for item := range strings.SplitSeq(value, "|") {
+			v, err := ParseParamValue(s.Items.Type, item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: default: %w", opts.Name, err)
+			}
+			values = append(values, v)
+		}
+		def = values
+	default:
+		v, err := ParseParamValue(s.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: default: %w", opts.Name, err)
+		}
+		def = v
+	}
+	resolved, err := s.Resolve(nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opts.Name, err)
+	}
+	if err := resolved.Validate(def); err != nil {
+		return nil, fmt.Errorf("%s: default %s violates the constraints: %w", opts.Name, value, err)
+	}
+	return def, nil
+}
+
+func paramSchema(typ reflect.Type, opts *ParamOpts) (*jsonschema.Schema, error) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if opts.In == openapi.ParamInCookie || typ == reflect.TypeFor[time.Time]() {
+		if opts.HasConstraints() {
+			return nil, fmt.Errorf("%s: constraints are not supported", opts.Name)
+		}
+		s := &jsonschema.Schema{Type: "string"}
+		if typ == reflect.TypeFor[time.Time]() {
+			s.Format = "date-time"
+		}
+		return s, nil
+	}
+	switch typ.Kind() {
+	case reflect.Slice:
+		items, err := ScalarParamSchema(jsonType(typ.Elem().Kind()), opts)
+		if err != nil {
+			return nil, err
+		}
+		enum(items, typ.Elem())
+		return &jsonschema.Schema{
+			Type:     "array",
+			Items:    items,
+			MinItems: opts.MinItems,
+			MaxItems: opts.MaxItems,
+		}, nil
+	case reflect.Map:
+		if opts.HasConstraints() {
+			return nil, fmt.Errorf("%s: constraints are not supported", opts.Name)
+		}
+		return &jsonschema.Schema{
+			Type:                 "object",
+			AdditionalProperties: &jsonschema.Schema{Type: "string"},
+		}, nil
+	}
+	if opts.MinItems != nil || opts.MaxItems != nil {
+		return nil, fmt.Errorf("%s: minItems and maxItems are only supported by arrays", opts.Name)
+	}
+	s, err := ScalarParamSchema(jsonType(typ.Kind()), opts)
+	if err != nil {
+		return nil, err
+	}
+	enum(s, typ)
+	return s, nil
+}
+
+// enum restricts s to the values of typ if it has a fixed set of values and
+// no enum is defined in the struct tag.
+func enum(s *jsonschema.Schema, typ reflect.Type) {
+	enumer, isEnumer := reflect.Zero(typ).Interface().(interface{ Enum() []any })
+	if isEnumer && len(s.Enum) == 0 {
+		s.Enum = enumer.Enum()
+	}
+}
+
+// ScalarParamSchema returns the schema of the JSON type typ including the
+// constraints of opts applicable to single values. The values of the enum
+// are converted to typ.
+func ScalarParamSchema(typ string, opts *ParamOpts) (*jsonschema.Schema, error) {
+	s := &jsonschema.Schema{Type: typ}
+	isNumber := typ == "integer" || typ == "number"
+	if !isNumber && (opts.Minimum != nil || opts.Maximum != nil || opts.MultipleOf != nil) {
+		return nil, fmt.Errorf("%s: min, max and multipleOf are only supported by numbers", opts.Name)
+	}
+	if typ != "string" && (opts.MinLength != nil || opts.MaxLength != nil || opts.Pattern != "") {
+		return nil, fmt.Errorf("%s: minLength, maxLength and pattern are only supported by strings", opts.Name)
+	}
+	s.Minimum, s.Maximum, s.MultipleOf = opts.Minimum, opts.Maximum, opts.MultipleOf
+	s.MinLength, s.MaxLength, s.Pattern = opts.MinLength, opts.MaxLength, opts.Pattern
+	for _, value := range opts.Enum {
+		v, err := ParseParamValue(typ, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: enum: %w", opts.Name, err)
+		}
+		s.Enum = append(s.Enum, v)
+	}
+	return s, nil
+}
+
+// ParseParamValue converts the value defined in a struct tag to the JSON type
+// typ.
+func ParseParamValue(typ, value string) (any, error) {
+	var v any
+	var err error
+	switch typ {
+	case "string":
+		v = value
+	case "integer":
+		v, err = strconv.ParseInt(value, 10, 64)
+	case "number":
+		v, err = strconv.ParseFloat(value, 64)
+	case "boolean":
+		v, err = strconv.ParseBool(value)
+	default:
+		err = errors.ErrUnsupported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("value is not of type %s: %s", typ, value)
+	}
+	return v, nil
+}
+
+// jsonType returns the JSON type of values of kind.
+func jsonType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
 func NewPathParam(opts *ParamOpts) (*openapi.Parameter, error) {
 	switch opts.Style {
 	case openapi.ParamStyleSimple:
@@ -198,3 +477,19 @@ func NewQueryParam(opts *ParamOpts) (*openapi.Parameter, error) {
 		Explode:    opts.Explode,
 	}, nil
 }
+
+// NewParam returns the parameter described by opts at its location.
+func NewParam(opts *ParamOpts) (*openapi.Parameter, error) {
+	switch opts.In {
+	case openapi.ParamInPath:
+		return NewPathParam(opts)
+	case openapi.ParamInQuery:
+		return NewQueryParam(opts)
+	case openapi.ParamInHeader:
+		return NewHeaderParam(opts)
+	case openapi.ParamInCookie:
+		return NewCookieParam(opts)
+	default:
+		return nil, fmt.Errorf("invalid parameter location: %s", opts.In)
+	}
+}
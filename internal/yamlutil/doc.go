@@ -0,0 +1,2 @@
+// Package yamlutil
+package yamlutil
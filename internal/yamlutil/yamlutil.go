@@ -0,0 +1,183 @@
+package yamlutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const indentSize = 2
+
+// plainKey matches keys which can be written without quotes.
+var plainKey = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$.\-]*$`)
+
+type (
+	// object is a JSON object preserving the order of its members.
+	object []member
+	array  []any
+	// scalar is a JSON string, number, boolean or null formatted as YAML.
+	scalar string
+)
+
+type member struct {
+	key   string
+	value any
+}
+
+// FromJSON converts the JSON document data into an equivalent YAML 1.2
+// document. The order of the object members is preserved.
+func FromJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decode(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON document")
+	}
+	var b bytes.Buffer
+	switch val := v.(type) {
+	case object:
+		writeObject(&b, val, 0, true)
+	case array:
+		writeArray(&b, val, 0)
+	default:
+		b.WriteString(inline(v))
+		b.WriteByte('\n')
+	}
+	return b.Bytes(), nil
+}
+
+func decode(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			obj := object{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decode(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj = append(obj, member{key: key.(string), value: value})
+			}
+			_, err := dec.Token()
+			return obj, err
+		}
+		arr := array{}
+		for dec.More() {
+			value, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		_, err := dec.Token()
+		return arr, err
+	case string:
+		// JSON strings are valid YAML double-quoted scalars
+		s, err := json.Marshal(t)
+		return scalar(s), err
+	case json.Number:
+		return scalar(t.String()), nil
+	case bool:
+		if t {
+			return scalar("true"), nil
+		}
+		return scalar("false"), nil
+	default:
+		return scalar("null"), nil
+	}
+}
+
+// writeObject writes obj in block style. If indentFirst is false the first
+// member is written without indentation e.g. following a sequence indicator.
+func writeObject(b *bytes.Buffer, obj object, indent int, indentFirst bool) {
+	for i, m := range obj {
+		if i > 0 || indentFirst {
+			b.WriteString(strings.Repeat(" ", indent))
+		}
+		b.WriteString(key(m.key))
+		b.WriteByte(':')
+		switch val := m.value.(type) {
+		case object:
+			if len(val) > 0 {
+				b.WriteByte('\n')
+				writeObject(b, val, indent+indentSize, true)
+				continue
+			}
+		case array:
+			if len(val) > 0 {
+				b.WriteByte('\n')
+				writeArray(b, val, indent+indentSize)
+				continue
+			}
+		}
+		b.WriteByte(' ')
+		b.WriteString(inline(m.value))
+		b.WriteByte('\n')
+	}
+}
+
+func writeArray(b *bytes.Buffer, arr array, indent int) {
+	for _, item := range arr {
+		b.WriteString(strings.Repeat(" ", indent))
+		b.WriteByte('-')
+		switch val := item.(type) {
+		case object:
+			if len(val) > 0 {
+				b.WriteByte(' ')
+				writeObject(b, val, indent+indentSize, false)
+				continue
+			}
+		case array:
+			if len(val) > 0 {
+				b.WriteByte('\n')
+				writeArray(b, val, indent+indentSize)
+				continue
+			}
+		}
+		b.WriteByte(' ')
+		b.WriteString(inline(item))
+		b.WriteByte('\n')
+	}
+}
+
+// inline returns the flow representation of scalars and empty collections.
+func inline(v any) string {
+	switch val := v.(type) {
+	case object:
+		return "{}"
+	case array:
+		return "[]"
+	case scalar:
+		return string(val)
+	default:
+		return "null"
+	}
+}
+
+// key returns k quoted if it would not be read as a string e.g. the plain
+// scalars True or NULL.
+func key(k string) string {
+	switch strings.ToLower(k) {
+	case "true", "false", "null", "yes", "no", "on", "off", "y", "n", "~":
+	default:
+		if plainKey.MatchString(k) {
+			return k
+		}
+	}
+	quoted, _ := json.Marshal(k)
+	return string(quoted)
+}
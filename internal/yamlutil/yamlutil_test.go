@@ -0,0 +1,42 @@
+package yamlutil_test
+
+import (
+	"testing"
+
+	"github.com/naivary/nuage/internal/yamlutil"
+)
+
+func TestFromJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		yaml string
+	}{
+		{
+			name: "nested",
+			json: `{"openapi":"3.1.0","info":{"title":"api"},"tags":["a",1,true,null],"paths":{}}`,
+			yaml: "openapi: \"3.1.0\"\ninfo:\n  title: \"api\"\ntags:\n  - \"a\"\n  - 1\n  - true\n  - null\npaths: {}\n",
+		},
+		{
+			name: "keys which are not plain",
+			json: `{"/users/{id}":1,"200":2,"x-key":3}`,
+			yaml: "\"/users/{id}\": 1\n\"200\": 2\nx-key: 3\n",
+		},
+		{
+			name: "reserved keys",
+			json: `{"true":1,"True":2,"TRUE":3,"False":4,"null":5,"Null":6,"NULL":7,"Yes":8,"OFF":9,"N":10,"~":11}`,
+			yaml: "\"true\": 1\n\"True\": 2\n\"TRUE\": 3\n\"False\": 4\n\"null\": 5\n\"Null\": 6\n\"NULL\": 7\n\"Yes\": 8\n\"OFF\": 9\n\"N\": 10\n\"~\": 11\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := yamlutil.FromJSON([]byte(tc.json))
+			if err != nil {
+				t.Fatalf("from json: %v", err)
+			}
+			if string(got) != tc.yaml {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.yaml)
+			}
+		})
+	}
+}
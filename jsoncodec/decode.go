@@ -0,0 +1,707 @@
+package jsoncodec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// DefaultMaxDepth is the maximum nesting depth of objects and arrays if not
+// configured otherwise.
+const DefaultMaxDepth = 64
+
+// DecodeError is the error of a JSON document which could not be decoded.
+type DecodeError struct {
+	// Pointer is the JSON Pointer (RFC 6901) of the value which could not
+	// be decoded. It is empty for the whole document.
+	Pointer string
+
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Pointer == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Pointer, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecoderOptions configures a [Decoder].
+type DecoderOptions struct {
+	// Strict rejects object members which are not known by the decoded
+	// type.
+	Strict bool
+
+	// MaxDepth is the maximum nesting depth of objects and arrays. If zero
+	// DefaultMaxDepth is used.
+	MaxDepth int
+}
+
+type segment struct {
+	key   string
+	index int
+	isKey bool
+}
+
+// Decoder decodes a JSON document without reflection. It is used by the
+// generated decoders of request bodies. Errors are sticky: after the first
+// error all methods are no-ops and the error is returned by [Decoder.Finish].
+type Decoder struct {
+	data []byte
+	pos  int
+	opts DecoderOptions
+
+	// path of the currently decoded value
+	path []segment
+
+	// errp is pointing to the first error shared with all sub-decoders
+	errp *error
+	err  error
+}
+
+// NewDecoder returns a Decoder of the JSON document data.
+func NewDecoder(data []byte, opts DecoderOptions) *Decoder {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DefaultMaxDepth
+	}
+	d := &Decoder{data: data, opts: opts}
+	d.errp = &d.err
+	return d
+}
+
+// Finish returns the first error which occurred while decoding. Any data
+// following the document is treated as an error.
+func (d *Decoder) Finish() error {
+	d.skipWS()
+	if !d.failed() && d.pos < len(d.data) {
+		d.fail(errors.New("unexpected data after JSON document"))
+	}
+	return *d.errp
+}
+
+func (d *Decoder) failed() bool {
+	return *d.errp != nil
+}
+
+func (d *Decoder) fail(err error) {
+	if d.failed() {
+		return
+	}
+	*d.errp = &DecodeError{Pointer: d.pointer(), Err: err}
+}
+
+func (d *Decoder) failf(format string, args ...any) {
+	d.fail(fmt.Errorf(format, args...))
+}
+
+// pointer returns the JSON Pointer of the current value.
+func (d *Decoder) pointer() string {
+	var b strings.Builder
+	for _, seg := range d.path {
+		b.WriteByte('/')
+		if seg.isKey {
+			b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(seg.key))
+		} else {
+			b.WriteString(strconv.Itoa(seg.index))
+		}
+	}
+	return b.String()
+}
+
+func (d *Decoder) skipWS() {
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case ' ', '\t', '\n', '\r':
+			d.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the first byte of the next value or 0 at the end of data.
+func (d *Decoder) peek() byte {
+	d.skipWS()
+	if d.pos >= len(d.data) {
+		return 0
+	}
+	return d.data[d.pos]
+}
+
+func (d *Decoder) consume(c byte) bool {
+	if d.peek() != c {
+		return false
+	}
+	d.pos++
+	return true
+}
+
+// Null consumes the literal null and reports whether the next value is null.
+// It also reports true after an error to stop the decoding of the value.
+func (d *Decoder) Null() bool {
+	if d.failed() {
+		return true
+	}
+	if d.peek() == 'n' && bytes.HasPrefix(d.data[d.pos:], []byte("null")) {
+		d.pos += 4
+		return true
+	}
+	return false
+}
+
+// Object returns the keys of the members of the next object. The value of
+// every member has to be decoded or skipped before the next key is read.
+func (d *Decoder) Object() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		if !d.begin('{', "object") {
+			return
+		}
+		d.path = append(d.path, segment{isKey: true})
+		for i := 0; !d.failed(); i++ {
+			if d.consume('}') {
+				d.path = d.path[:len(d.path)-1]
+				return
+			}
+			if i > 0 && !d.consume(',') {
+				d.failf("expected , or } after object member")
+				return
+			}
+			if d.peek() != '"' {
+				d.failf("expected string as object key")
+				return
+			}
+			key := d.readString()
+			if !d.consume(':') {
+				d.failf("expected : after object key")
+				return
+			}
+			d.path[len(d.path)-1] = segment{key: key, isKey: true}
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Array returns the indexes of the elements of the next array. Every element
+// has to be decoded or skipped before the next index is returned.
+func (d *Decoder) Array() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		if !d.begin('[', "array") {
+			return
+		}
+		d.path = append(d.path, segment{})
+		for i := 0; !d.failed(); i++ {
+			if d.consume(']') {
+				d.path = d.path[:len(d.path)-1]
+				return
+			}
+			if i > 0 && !d.consume(',') {
+				d.failf("expected , or ] after array element")
+				return
+			}
+			d.path[len(d.path)-1] = segment{index: i}
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+// begin consumes the opening delimiter of an object or array.
+func (d *Decoder) begin(delim byte, kind string) bool {
+	if d.failed() {
+		return false
+	}
+	if d.peek() != delim {
+		d.typeError(kind)
+		return false
+	}
+	if len(d.path) >= d.opts.MaxDepth {
+		d.failf("exceeds the maximum depth of %d", d.opts.MaxDepth)
+		return false
+	}
+	d.pos++
+	return true
+}
+
+// Unknown handles the value of an object member with the unknown key. It is
+// skipped or rejected if the decoder is strict.
+func (d *Decoder) Unknown(key string) {
+	if d.opts.Strict {
+		d.failf("unknown field %q", key)
+		return
+	}
+	d.Skip()
+}
+
+// Skip skips the next value.
+func (d *Decoder) Skip() {
+	d.skipValue(len(d.path))
+}
+
+// Raw returns the next value as it is.
+func (d *Decoder) Raw() []byte {
+	d.skipWS()
+	start := d.pos
+	d.skipValue(len(d.path))
+	if d.failed() {
+		return nil
+	}
+	return d.data[start:d.pos]
+}
+
+func (d *Decoder) skipValue(depth int) {
+	if d.failed() {
+		return
+	}
+	switch c := d.peek(); {
+	case c == '{' || c == '[':
+		if depth >= d.opts.MaxDepth {
+			d.failf("exceeds the maximum depth of %d", d.opts.MaxDepth)
+			return
+		}
+		d.pos++
+		end := byte('}')
+		if c == '[' {
+			end = ']'
+		}
+		for i := 0; !d.failed(); i++ {
+			if d.consume(end) {
+				return
+			}
+			if i > 0 && !d.consume(',') {
+				d.failf("expected , or %c", end)
+				return
+			}
+			if c == '{' {
+				if d.peek() != '"' {
+					d.failf("expected string as object key")
+					return
+				}
+				d.readString()
+				if !d.consume(':') {
+					d.failf("expected : after object key")
+					return
+				}
+			}
+			d.skipValue(depth + 1)
+		}
+	case c == '"':
+		d.readString()
+	case c == '-' || c >= '0' && c <= '9':
+		d.readNumber()
+	default:
+		d.readLiteral()
+	}
+}
+
+// readLiteral consumes true, false or null.
+func (d *Decoder) readLiteral() string {
+	for _, lit := range []string{"true", "false", "null"} {
+		if bytes.HasPrefix(d.data[d.pos:], []byte(lit)) {
+			d.pos += len(lit)
+			return lit
+		}
+	}
+	if d.pos >= len(d.data) {
+		d.failf("unexpected end of JSON input")
+	} else {
+		d.failf("invalid character %q looking for beginning of value", d.data[d.pos])
+	}
+	return ""
+}
+
+// readNumber consumes a number and returns its literal.
+func (d *Decoder) readNumber() []byte {
+	start := d.pos
+	i := d.pos
+	if i < len(d.data) && d.data[i] == '-' {
+		i++
+	}
+	digits := func() int {
+		n := 0
+		for i < len(d.data) && d.data[i] >= '0' && d.data[i] <= '9' {
+			i++
+			n++
+		}
+		return n
+	}
+	switch {
+	case i < len(d.data) && d.data[i] == '0':
+		i++
+	case digits() == 0:
+		d.failf("invalid number")
+		return nil
+	}
+	if i < len(d.data) && d.data[i] == '.' {
+		i++
+		if digits() == 0 {
+			d.failf("invalid number")
+			return nil
+		}
+	}
+	if i < len(d.data) && (d.data[i] == 'e' || d.data[i] == 'E') {
+		i++
+		if i < len(d.data) && (d.data[i] == '+' || d.data[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			d.failf("invalid number")
+			return nil
+		}
+	}
+	d.pos = i
+	return d.data[start:i]
+}
+
+// readString consumes a string and returns its unescaped value. Invalid UTF-8
+// is replaced by the Unicode replacement character.
+func (d *Decoder) readString() string {
+	d.pos++ // opening quote
+	start := d.pos
+	// fast path without escapes
+	for i := start; i < len(d.data); i++ {
+		c := d.data[i]
+		if c == '"' {
+			if utf8.Valid(d.data[start:i]) {
+				d.pos = i + 1
+				return string(d.data[start:i])
+			}
+			break
+		}
+		if c == '\\' || c < 0x20 {
+			break
+		}
+	}
+	var b []byte
+	for i := start; i < len(d.data); {
+		c := d.data[i]
+		switch {
+		case c == '"':
+			d.pos = i + 1
+			return string(b)
+		case c < 0x20:
+			d.failf("invalid character %q in string literal", c)
+			return ""
+		case c == '\\':
+			if i+1 >= len(d.data) {
+				i++
+				continue
+			}
+			switch esc := d.data[i+1]; esc {
+			case '"', '\\', '/':
+				b = append(b, esc)
+			case 'b':
+				b = append(b, '\b')
+			case 'f':
+				b = append(b, '\f')
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case 't':
+				b = append(b, '\t')
+			case 'u':
+				r, n := d.readUnicode(i)
+				if n == 0 {
+					d.failf("invalid unicode escape in string literal")
+					return ""
+				}
+				b = utf8.AppendRune(b, r)
+				i += n
+				continue
+			default:
+				d.failf("invalid escape %q in string literal", esc)
+				return ""
+			}
+			i += 2
+		case c < utf8.RuneSelf:
+			b = append(b, c)
+			i++
+		default:
+			r, size := utf8.DecodeRune(d.data[i:])
+			b = utf8.AppendRune(b, r)
+			i += size
+		}
+	}
+	d.failf("unexpected end of JSON input")
+	return ""
+}
+
+// readUnicode decodes the \uXXXX escape at i including surrogate pairs. It
+// returns the rune and the length of the escape or 0 if it is invalid.
+func (d *Decoder) readUnicode(i int) (rune, int) {
+	hex4 := func(i int) rune {
+		if i+6 > len(d.data) || d.data[i] != '\\' || d.data[i+1] != 'u' {
+			return -1
+		}
+		v, err := strconv.ParseUint(string(d.data[i+2:i+6]), 16, 16)
+		if err != nil {
+			return -1
+		}
+		return rune(v)
+	}
+	r := hex4(i)
+	if r < 0 {
+		return 0, 0
+	}
+	if !utf16.IsSurrogate(r) {
+		return r, 6
+	}
+	if r2 := hex4(i + 6); r2 >= 0 {
+		if dec := utf16.DecodeRune(r, r2); dec != utf8.RuneError {
+			return dec, 12
+		}
+	}
+	return utf8.RuneError, 6
+}
+
+// typeError fails because the next value is not of the expected kind.
+func (d *Decoder) typeError(want string) {
+	var got string
+	switch c := d.peek(); {
+	case c == '{':
+		got = "object"
+	case c == '[':
+		got = "array"
+	case c == '"':
+		got = "string"
+	case c == 't' || c == 'f':
+		got = "boolean"
+	case c == '-' || c >= '0' && c <= '9':
+		got = "number"
+	default:
+		// report the syntax error instead
+		d.skipValue(len(d.path))
+		got = "value"
+	}
+	d.failf("cannot unmarshal %s into %s", got, want)
+}
+
+// str returns the next string. The value is left untouched if it is null.
+func (d *Decoder) str(want string) (string, bool) {
+	if d.Null() {
+		return "", false
+	}
+	if d.peek() != '"' {
+		d.typeError(want)
+		return "", false
+	}
+	s := d.readString()
+	return s, !d.failed()
+}
+
+// number returns the literal of the next number.
+func (d *Decoder) number(want string) ([]byte, bool) {
+	if d.Null() {
+		return nil, false
+	}
+	if c := d.peek(); c != '-' && (c < '0' || c > '9') {
+		d.typeError(want)
+		return nil, false
+	}
+	lit := d.readNumber()
+	return lit, !d.failed()
+}
+
+// Quoted returns a decoder for the JSON value encoded in the next string as
+// defined by the `string` option of encoding/json. It has to be closed after
+// decoding the value.
+func (d *Decoder) Quoted() *Decoder {
+	q := &Decoder{opts: d.opts, path: d.path, errp: d.errp}
+	if d.Null() {
+		q.data = []byte("null")
+		return q
+	}
+	s, _ := d.str("string")
+	q.data = []byte(s)
+	return q
+}
+
+// Close reports an error if the quoted value was not consumed completely.
+func (d *Decoder) Close() {
+	d.skipWS()
+	if d.pos < len(d.data) {
+		d.failf("invalid use of ,string struct tag")
+	}
+}
+
+// Unmarshaler decodes the next value using u.
+func (d *Decoder) Unmarshaler(u json.Unmarshaler) {
+	raw := d.Raw()
+	if d.failed() {
+		return
+	}
+	if err := u.UnmarshalJSON(raw); err != nil {
+		d.fail(err)
+	}
+}
+
+// Text decodes the next string using u. The value is left untouched if it is
+// null.
+func (d *Decoder) Text(u encoding.TextUnmarshaler) {
+	s, ok := d.str("string")
+	if !ok {
+		return
+	}
+	if err := u.UnmarshalText([]byte(s)); err != nil {
+		d.fail(err)
+	}
+}
+
+// Any decodes the next value into v using encoding/json. It is used for
+// values which cannot be decoded without reflection e.g. interfaces.
+func (d *Decoder) Any(v any) {
+	raw := d.Raw()
+	if d.failed() {
+		return
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if d.opts.Strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		d.fail(err)
+	}
+}
+
+func DecodeBool[T ~bool](d *Decoder, p *T) {
+	if d.Null() {
+		return
+	}
+	switch c := d.peek(); c {
+	case 't', 'f':
+		if lit := d.readLiteral(); lit != "" {
+			*p = T(lit == "true")
+		}
+	default:
+		d.typeError("boolean")
+	}
+}
+
+func DecodeString[T ~string](d *Decoder, p *T) {
+	if s, ok := d.str("string"); ok {
+		*p = T(s)
+	}
+}
+
+func DecodeInt[T ~int | ~int8 | ~int16 | ~int32 | ~int64](d *Decoder, p *T, bits int) {
+	lit, ok := d.number("integer")
+	if !ok {
+		return
+	}
+	v, err := strconv.ParseInt(string(lit), 10, bits)
+	if err != nil {
+		d.failf("cannot unmarshal number %s into int%d", lit, bits)
+		return
+	}
+	*p = T(v)
+}
+
+func DecodeUint[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr](d *Decoder, p *T, bits int) {
+	lit, ok := d.number("unsigned integer")
+	if !ok {
+		return
+	}
+	v, err := strconv.ParseUint(string(lit), 10, bits)
+	if err != nil {
+		d.failf("cannot unmarshal number %s into uint%d", lit, bits)
+		return
+	}
+	*p = T(v)
+}
+
+func DecodeFloat[T ~float32 | ~float64](d *Decoder, p *T, bits int) {
+	lit, ok := d.number("number")
+	if !ok {
+		return
+	}
+	v, err := strconv.ParseFloat(string(lit), bits)
+	if err != nil {
+		d.failf("cannot unmarshal number %s into float%d", lit, bits)
+		return
+	}
+	*p = T(v)
+}
+
+// DecodeBytes decodes the next base64 encoded string.
+func DecodeBytes[T ~[]byte](d *Decoder, p *T) {
+	if d.Null() {
+		*p = nil
+		return
+	}
+	s, ok := d.str("string")
+	if !ok {
+		return
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		d.fail(err)
+		return
+	}
+	*p = T(b)
+}
+
+// Enum fails if the decoded value v is not one of values. It is used for
+// named types with typed constants.
+func Enum[T comparable](d *Decoder, v T, values ...T) {
+	if d.failed() || slices.Contains(values, v) {
+		return
+	}
+	d.failf("value %v must be one of %v", v, values)
+}
+
+// Alloc allocates the value of the pointer p is pointing to if it is nil.
+func Alloc[T any](p **T) {
+	if *p == nil {
+		*p = new(T)
+	}
+}
+
+// Reset truncates the slice p is pointing to. A nil slice is replaced by an
+// empty slice.
+func Reset[S ~[]E, E any](p *S) {
+	if *p == nil {
+		*p = make(S, 0)
+		return
+	}
+	*p = (*p)[:0]
+}
+
+// Extend returns s with an additional zero element.
+func Extend[S ~[]E, E any](s S) S {
+	var zero E
+	return append(s, zero)
+}
+
+// InitMap creates the map p is pointing to if it is nil.
+func InitMap[M ~map[K]V, K ~string, V any](p *M) {
+	if *p == nil {
+		*p = make(M)
+	}
+}
+
+// ZeroValue returns the zero value of the values of m.
+func ZeroValue[M ~map[K]V, K comparable, V any](m M) V {
+	var zero V
+	return zero
+}
+
+// SetMapValue sets the value of key in m.
+func SetMapValue[M ~map[K]V, K ~string, V any](m M, key string, v V) {
+	m[K(key)] = v
+}
@@ -0,0 +1,123 @@
+package jsoncodec_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/naivary/nuage/jsoncodec"
+)
+
+type point struct {
+	X, Y int
+}
+
+// decodePoints decodes data like a generated decoder of []point.
+func decodePoints(data string, opts jsoncodec.DecoderOptions) ([]point, error) {
+	var points []point
+	d := jsoncodec.NewDecoder([]byte(data), opts)
+	if d.Null() {
+		points = nil
+	} else {
+		jsoncodec.Reset(&points)
+		for i := range d.Array() {
+			points = jsoncodec.Extend(points)
+			for key := range d.Object() {
+				switch key {
+				case "x":
+					jsoncodec.DecodeInt(d, &points[i].X, 64)
+				case "y":
+					jsoncodec.DecodeInt(d, &points[i].Y, 64)
+				default:
+					d.Unknown(key)
+				}
+			}
+		}
+	}
+	return points, d.Finish()
+}
+
+func TestDecoder(t *testing.T) {
+	points, err := decodePoints(` [{"x":1,"y":-2,"z":{"ignored":[true]}}, {"y":3}] `, jsoncodec.DecoderOptions{})
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(points) != 2 || points[0] != (point{1, -2}) || points[1] != (point{0, 3}) {
+		t.Errorf("unexpected points: %v", points)
+	}
+}
+
+func TestDecoder_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		opts    jsoncodec.DecoderOptions
+		pointer string
+	}{
+		{name: "type", data: `[{"x":1},{"y":"2"}]`, pointer: "/1/y"},
+		{name: "overflow", data: `[{"x":1e100}]`, pointer: "/0/x"},
+		{name: "strict", data: `[{"a/b":1}]`, opts: jsoncodec.DecoderOptions{Strict: true}, pointer: "/0/a~1b"},
+		{name: "depth", data: `[{"z":[[1]]}]`, opts: jsoncodec.DecoderOptions{MaxDepth: 3}, pointer: "/0/z"},
+		{name: "syntax", data: `[{"x":1]`, pointer: "/0/x"},
+		{name: "trailing data", data: `[] []`, pointer: ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := decodePoints(tc.data, tc.opts)
+			var decodeErr *jsoncodec.DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("expected DecodeError: %v", err)
+			}
+			if decodeErr.Pointer != tc.pointer {
+				t.Errorf("pointer: got %q want %q (%v)", decodeErr.Pointer, tc.pointer, err)
+			}
+		})
+	}
+}
+
+func TestDecodeString(t *testing.T) {
+	tests := map[string]string{
+		`"plain"`:                   "plain",
+		`"esc \" \\ \/ \b\f\n\r\t"`: "esc \" \\ / \b\f\n\r\t",
+		`"ä🚀"`:                      "ä🚀",
+		`"lone \ud83d"`:             "lone �",
+	}
+	for data, want := range tests {
+		var got string
+		d := jsoncodec.NewDecoder([]byte(data), jsoncodec.DecoderOptions{})
+		jsoncodec.DecodeString(d, &got)
+		if err := d.Finish(); err != nil || got != want {
+			t.Errorf("DecodeString(%s): got %q, %v want %q", data, got, err, want)
+		}
+	}
+	var got string
+	d := jsoncodec.NewDecoder([]byte(`"unterminated`), jsoncodec.DecoderOptions{})
+	jsoncodec.DecodeString(d, &got)
+	if err := d.Finish(); err == nil {
+		t.Errorf("unterminated string is accepted: %q", got)
+	}
+}
+
+func TestEnum(t *testing.T) {
+	type status string
+	decode := func(data string) error {
+		var s status
+		d := jsoncodec.NewDecoder([]byte(data), jsoncodec.DecoderOptions{})
+		for key := range d.Object() {
+			switch key {
+			case "status":
+				jsoncodec.DecodeString(d, &s)
+				jsoncodec.Enum(d, s, "active", "inactive")
+			default:
+				d.Unknown(key)
+			}
+		}
+		return d.Finish()
+	}
+	if err := decode(`{"status":"active"}`); err != nil {
+		t.Errorf("decode: %v", err)
+	}
+	var decodeErr *jsoncodec.DecodeError
+	if err := decode(`{"status":"pending"}`); !errors.As(err, &decodeErr) || decodeErr.Pointer != "/status" {
+		t.Errorf("expected error at /status: got %v", err)
+	}
+}
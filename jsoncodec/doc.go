@@ -0,0 +1,8 @@
+// Package jsoncodec contains the helpers used by the JSON encoders and the
+// JSON decoders of request bodies generated by nuage. The produced encoding is
+// equal to the one of encoding/json without relying on reflection. The
+// [Decoder] is decoding a document following the rules of encoding/json and
+// reports the JSON Pointer of the value which could not be decoded.
+// Additionally it enforces a maximum nesting depth and optionally rejects
+// unknown object members.
+package jsoncodec
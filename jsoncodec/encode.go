@@ -0,0 +1,229 @@
+package jsoncodec
+
+import (
+	"bytes"
+	"cmp"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Appender is implemented by the generated response models.
+type Appender interface {
+	// AppendJSON appends the JSON encoding of the value to b.
+	AppendJSON(b []byte) ([]byte, error)
+}
+
+// maxPooledBufSize is the maximum capacity of a buffer which is returned to
+// the pool.
+const maxPooledBufSize = 64 << 10
+
+var bufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+// Encode writes the JSON encoding of v followed by a newline to w like
+// [json.Encoder.Encode].
+func Encode(w io.Writer, v Appender) error {
+	buf := bufPool.Get().(*[]byte)
+	defer func() {
+		if cap(*buf) <= maxPooledBufSize {
+			bufPool.Put(buf)
+		}
+	}()
+	b, err := v.AppendJSON((*buf)[:0])
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	*buf = b
+	_, err = w.Write(b)
+	return err
+}
+
+// AppendKey appends the pre-encoded object key including the colon to b. A
+// comma is added if b is not at the start of an object.
+func AppendKey(b []byte, key string) []byte {
+	if len(b) > 0 && b[len(b)-1] != '{' {
+		b = append(b, ',')
+	}
+	return append(b, key...)
+}
+
+func AppendBool(b []byte, v bool) []byte {
+	return strconv.AppendBool(b, v)
+}
+
+func AppendInt(b []byte, v int64) []byte {
+	return strconv.AppendInt(b, v, 10)
+}
+
+func AppendUint(b []byte, v uint64) []byte {
+	return strconv.AppendUint(b, v, 10)
+}
+
+// AppendFloat appends f formatted like encoding/json with the given bit size.
+// NaN and infinite values cannot be represented in JSON.
+func AppendFloat(b []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, fmt.Errorf("jsoncodec: unsupported value: %s", strconv.FormatFloat(f, 'g', -1, bits))
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b, nil
+}
+
+const hex = "0123456789abcdef"
+
+// AppendString appends s as JSON string to b. Like encoding/json the HTML
+// characters <, > and & are escaped and invalid UTF-8 is replaced by the
+// Unicode replacement character.
+func AppendString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are not valid in JavaScript strings
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
+
+// AppendBytes appends p as base64 encoded JSON string to b. A nil slice is
+// encoded as null.
+func AppendBytes(b []byte, p []byte) []byte {
+	if p == nil {
+		return append(b, "null"...)
+	}
+	b = append(b, '"')
+	b = base64.StdEncoding.AppendEncode(b, p)
+	return append(b, '"')
+}
+
+// AppendTime appends t formatted as RFC 3339 JSON string to b like
+// [time.Time.MarshalJSON].
+func AppendTime(b []byte, t time.Time) ([]byte, error) {
+	if y := t.Year(); y < 0 || y >= 10000 {
+		return nil, errors.New("jsoncodec: year outside of range [0,9999]")
+	}
+	b = append(b, '"')
+	b = t.AppendFormat(b, time.RFC3339Nano)
+	return append(b, '"'), nil
+}
+
+// AppendMarshaler appends the compacted output of m.MarshalJSON to b.
+func AppendMarshaler(b []byte, m json.Marshaler) ([]byte, error) {
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, err
+	}
+	dst := bytes.NewBuffer(b)
+	json.HTMLEscape(dst, compact.Bytes())
+	return dst.Bytes(), nil
+}
+
+// AppendText appends the output of m.MarshalText as JSON string to b.
+func AppendText(b []byte, m encoding.TextMarshaler) ([]byte, error) {
+	text, err := m.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return AppendString(b, string(text)), nil
+}
+
+// AppendAny appends the JSON encoding of v to b using encoding/json. It is
+// used for values which cannot be encoded without reflection e.g. interfaces.
+func AppendAny(b []byte, v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, data...), nil
+}
+
+// SortedKeys returns the keys of m in sorted order. encoding/json is sorting
+// the keys of maps.
+func SortedKeys[M ~map[K]V, K cmp.Ordered, V any](m M) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// IsZero reports whether v is the zero value of its type.
+func IsZero[T comparable](v T) bool {
+	var zero T
+	return v == zero
+}
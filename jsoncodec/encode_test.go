@@ -0,0 +1,63 @@
+package jsoncodec_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/naivary/nuage/jsoncodec"
+)
+
+func TestAppendString(t *testing.T) {
+	tests := []string{
+		"",
+		"plain",
+		`quote " and backslash \`,
+		"<html> & </html>",
+		"control \b\f\n\r\t\x00\x1f",
+		"unicode äöü 🚀",
+		"separators \u2028\u2029",
+		"invalid \xff utf-8",
+	}
+	for _, s := range tests {
+		want, err := json.Marshal(s)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if got := jsoncodec.AppendString(nil, s); string(got) != string(want) {
+			t.Errorf("AppendString(%q): got %s, want %s", s, got, want)
+		}
+	}
+}
+
+func TestAppendFloat(t *testing.T) {
+	tests := []float64{0, 1, -1.5, 1e-7, 1e21, 123456789.123, math.MaxFloat64, math.SmallestNonzeroFloat64}
+	for _, f := range tests {
+		want, _ := json.Marshal(f)
+		got, err := jsoncodec.AppendFloat(nil, f, 64)
+		if err != nil || string(got) != string(want) {
+			t.Errorf("AppendFloat(%v, 64): got %s, want %s", f, got, want)
+		}
+		if math.IsInf(float64(float32(f)), 0) {
+			continue
+		}
+		want, _ = json.Marshal(float32(f))
+		got, err = jsoncodec.AppendFloat(nil, float64(float32(f)), 32)
+		if err != nil || string(got) != string(want) {
+			t.Errorf("AppendFloat(%v, 32): got %s, want %s", f, got, want)
+		}
+	}
+	if _, err := jsoncodec.AppendFloat(nil, math.NaN(), 64); err == nil {
+		t.Errorf("expected an error for NaN")
+	}
+}
+
+func TestAppendTime(t *testing.T) {
+	now := time.Date(2024, 2, 29, 12, 30, 0, 123, time.FixedZone("", 3600))
+	want, _ := json.Marshal(now)
+	got, err := jsoncodec.AppendTime(nil, now)
+	if err != nil || string(got) != string(want) {
+		t.Errorf("AppendTime: got %s, want %s", got, want)
+	}
+}
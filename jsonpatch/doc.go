@@ -0,0 +1,4 @@
+// Package jsonpatch implements JSON Patch as defined in RFC 6902 and JSON
+// Pointer as defined in RFC 6901. Patches are applied atomically: either all
+// operations succeed or the target is left untouched.
+package jsonpatch
@@ -0,0 +1,377 @@
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// operations of JSON Patch
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+// ErrTestFailed is the error of a test operation whose value is not equal to
+// the target.
+var ErrTestFailed = errors.New("test failed")
+
+// Error is the error of the operation of a patch which could not be applied.
+type Error struct {
+	// Index of the failed operation in the patch
+	Index int
+
+	// Op is the failed operation
+	Op string
+
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("operation %d (%s): %v", e.Index, e.Op, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Operation is a single operation of a patch.
+type Operation struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+
+	// From is the source location of move and copy operations
+	From string `json:"from,omitempty"`
+
+	// Value of add, replace and test operations. A nil Value is absent,
+	// while null is represented by the literal null.
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch is a JSON Patch document.
+type Patch []Operation
+
+// Apply applies the patch to the JSON document doc and returns the patched
+// document. doc is not modified. The members of objects are ordered by their
+// keys.
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	var v any
+	if err := unmarshal(doc, &v); err != nil {
+		return nil, err
+	}
+	v, err := p.apply(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// ApplyTo applies the patch to the JSON encoding of v. The result is decoded
+// into a new value replacing v if all operations succeeded.
+func ApplyTo[T any](v *T, p Patch) error {
+	if v == nil {
+		return errors.New("jsonpatch: target is nil")
+	}
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data, err := p.Apply(doc)
+	if err != nil {
+		return err
+	}
+	var patched T
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return fmt.Errorf("jsonpatch: patched document is invalid: %w", err)
+	}
+	*v = patched
+	return nil
+}
+
+func (p Patch) apply(doc any) (any, error) {
+	for i, op := range p {
+		var err error
+		doc, err = op.apply(doc)
+		if err != nil {
+			return nil, &Error{Index: i, Op: op.Op, Err: err}
+		}
+	}
+	return doc, nil
+}
+
+func (o Operation) apply(doc any) (any, error) {
+	path, err := ParsePointer(o.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch o.Op {
+	case OpAdd, OpReplace, OpTest:
+		if o.Value == nil {
+			return nil, errors.New("value is missing")
+		}
+		var value any
+		if err := unmarshal(o.Value, &value); err != nil {
+			return nil, err
+		}
+		switch o.Op {
+		case OpAdd:
+			return add(doc, path, value)
+		case OpReplace:
+			if _, err := get(doc, path); err != nil {
+				return nil, err
+			}
+			doc, _, err = remove(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		default:
+			target, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !equal(target, value) {
+				return nil, ErrTestFailed
+			}
+			return doc, nil
+		}
+	case OpRemove:
+		doc, _, err = remove(doc, path)
+		return doc, err
+	case OpMove, OpCopy:
+		from, err := ParsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+		if o.Op == OpCopy {
+			value, err := get(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, deepCopy(value))
+		}
+		if from.IsPrefixOf(path) {
+			return nil, errors.New("location cannot be moved into one of its children")
+		}
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	default:
+		return nil, fmt.Errorf("unknown operation: %q", o.Op)
+	}
+}
+
+// get returns the value referenced by path.
+func get(doc any, path Pointer) (any, error) {
+	for i, token := range path {
+		switch v := doc.(type) {
+		case map[string]any:
+			child, exists := v[token]
+			if !exists {
+				return nil, fmt.Errorf("path does not exist: %s", path[:i+1])
+			}
+			doc = child
+		case []any:
+			idx, err := arrayIndex(token, len(v))
+			if err != nil {
+				return nil, err
+			}
+			doc = v[idx]
+		default:
+			return nil, fmt.Errorf("path does not exist: %s", path[:i+1])
+		}
+	}
+	return doc, nil
+}
+
+// add adds value at path and returns the new document. The parent of path
+// has to exist. Arrays are modified by inserting before the index or
+// appending if the last reference token is "-".
+func add(doc any, path Pointer, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+	switch v := parent.(type) {
+	case map[string]any:
+		v[token] = value
+		return doc, nil
+	case []any:
+		idx := len(v)
+		if token != "-" {
+			idx, err = arrayIndex(token, len(v)+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return setParent(doc, path[:len(path)-1], slices.Insert(v, idx, value))
+	default:
+		return nil, fmt.Errorf("parent is not a container: %s", path[:len(path)-1])
+	}
+}
+
+// remove removes the value at path and returns the new document and the
+// removed value.
+func remove(doc any, path Pointer) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	token := path[len(path)-1]
+	switch v := parent.(type) {
+	case map[string]any:
+		value, exists := v[token]
+		if !exists {
+			return nil, nil, fmt.Errorf("path does not exist: %s", path)
+		}
+		delete(v, token)
+		return doc, value, nil
+	case []any:
+		idx, err := arrayIndex(token, len(v))
+		if err != nil {
+			return nil, nil, err
+		}
+		value := v[idx]
+		doc, err = setParent(doc, path[:len(path)-1], slices.Delete(slices.Clone(v), idx, idx+1))
+		return doc, value, err
+	default:
+		return nil, nil, fmt.Errorf("path does not exist: %s", path)
+	}
+}
+
+// setParent replaces the array at path by arr as slices cannot be modified
+// in place.
+func setParent(doc any, path Pointer, arr []any) (any, error) {
+	if len(path) == 0 {
+		return arr, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+	switch v := parent.(type) {
+	case map[string]any:
+		v[token] = arr
+	case []any:
+		idx, err := arrayIndex(token, len(v))
+		if err != nil {
+			return nil, err
+		}
+		v[idx] = arr
+	}
+	return doc, nil
+}
+
+// equal reports whether the JSON values a and b are equal. Numbers are
+// compared by their value and the order of object members is irrelevant.
+func equal(a, b any) bool {
+	switch x := a.(type) {
+	case map[string]any:
+		y, isObject := b.(map[string]any)
+		if !isObject || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, exists := y[k]
+			if !exists || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, isArray := b.([]any)
+		return isArray && slices.EqualFunc(x, y, equal)
+	case json.Number:
+		y, isNumber := b.(json.Number)
+		return isNumber && equalNumber(x, y)
+	default:
+		return a == b
+	}
+}
+
+// maxExactNumberLen is the maximum length of numbers compared exactly. Exact
+// arithmetic is expanding the exponent of a number which is expensive for
+// huge exponents e.g. 1e999999.
+const maxExactNumberLen = 32
+
+// equalNumber reports whether the numbers x and y are equal. Short numbers
+// are compared exactly. Otherwise they are compared as float64 if both are
+// within its range or by their literals.
+func equalNumber(x, y json.Number) bool {
+	if x == y {
+		return true
+	}
+	if isExact(x) && isExact(y) {
+		r, okX := new(big.Rat).SetString(string(x))
+		s, okY := new(big.Rat).SetString(string(y))
+		return okX && okY && r.Cmp(s) == 0
+	}
+	f, errX := strconv.ParseFloat(string(x), 64)
+	g, errY := strconv.ParseFloat(string(y), 64)
+	return errX == nil && errY == nil && f == g
+}
+
+// isExact reports whether n is short enough to be compared exactly.
+func isExact(n json.Number) bool {
+	if len(n) > maxExactNumberLen {
+		return false
+	}
+	_, exp, hasExp := strings.Cut(strings.ToLower(string(n)), "e")
+	if !hasExp {
+		return true
+	}
+	e, err := strconv.Atoi(exp)
+	return err == nil && e >= -maxExactNumberLen*10 && e <= maxExactNumberLen*10
+}
+
+func deepCopy(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(x))
+		for k, v := range x {
+			c[k] = deepCopy(v)
+		}
+		return c
+	case []any:
+		c := make([]any, len(x))
+		for i, v := range x {
+			c[i] = deepCopy(v)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// unmarshal decodes data into v keeping the precision of numbers.
+func unmarshal(data []byte, v *any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if err := dec.Decode(&json.RawMessage{}); err != io.EOF {
+		return errors.New("unexpected data after JSON document")
+	}
+	return nil
+}
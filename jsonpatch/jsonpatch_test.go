@@ -0,0 +1,161 @@
+package jsonpatch_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/naivary/nuage/jsonpatch"
+)
+
+func TestParsePointer(t *testing.T) {
+	// examples of RFC 6901 Section 5
+	tests := map[string]jsonpatch.Pointer{
+		"":       {},
+		"/foo":   {"foo"},
+		"/foo/0": {"foo", "0"},
+		"/":      {""},
+		"/a~1b":  {"a/b"},
+		"/m~0n":  {"m~n"},
+		"/~01":   {"~1"},
+	}
+	for s, want := range tests {
+		got, err := jsonpatch.ParsePointer(s)
+		if err != nil {
+			t.Errorf("ParsePointer(%q): %v", s, err)
+			continue
+		}
+		if len(got) != len(want) || got.String() != s {
+			t.Errorf("ParsePointer(%q): got %q", s, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("ParsePointer(%q): got %q want %q", s, got, want)
+			}
+		}
+	}
+	for _, s := range []string{"foo", "/~", "/~2"} {
+		if _, err := jsonpatch.ParsePointer(s); err == nil {
+			t.Errorf("ParsePointer(%q): invalid pointer is accepted", s)
+		}
+	}
+}
+
+func TestPatch_Apply(t *testing.T) {
+	// examples of RFC 6902 Appendix A
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move member", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"test", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"add nested", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"child":{"grandchild":{}},"foo":"bar"}`},
+		{"ignore unknown members", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`, `{"baz":"qux","foo":"bar"}`},
+		{"append", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"null value", `{"foo":"bar"}`, `[{"op":"add","path":"/foo","value":null}]`, `{"foo":null}`},
+		{"escaped", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{"copy", `{"a":{"b":[1]}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/b/-","value":2}]`, `{"a":{"b":[1]},"c":{"b":[1,2]}}`},
+		{"replace root", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+		{"test long number", `{"a":12345678901234567890123456789012345}`, `[{"op":"test","path":"/a","value":1.2345678901234567890123456789012345e34}]`, `{"a":12345678901234567890123456789012345}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var p jsonpatch.Patch
+			if err := json.Unmarshal([]byte(tc.patch), &p); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			got, err := p.Apply([]byte(tc.doc))
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got %s want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPatch_Error(t *testing.T) {
+	tests := []struct {
+		name, doc, patch string
+		index            int
+		testFailed       bool
+	}{
+		{"missing target", `{"foo":"bar"}`, `[{"op":"test","path":"/foo","value":"bar"},{"op":"add","path":"/baz/bat","value":"qux"}]`, 1, false},
+		{"test failed", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, 0, true},
+		{"invalid index", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/01","value":"x"}]`, 0, false},
+		{"out of bounds", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"x"}]`, 0, false},
+		{"missing value", `{}`, `[{"op":"add","path":"/foo"}]`, 0, false},
+		{"move into child", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`, 0, false},
+		{"remove missing", `{}`, `[{"op":"remove","path":"/a"}]`, 0, false},
+		{"unknown op", `{}`, `[{"op":"merge","path":"/a"}]`, 0, false},
+		{"huge exponent", `{"a":1e999999}`, `[{"op":"test","path":"/a","value":2e999999}]`, 0, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var p jsonpatch.Patch
+			if err := json.Unmarshal([]byte(tc.patch), &p); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			_, err := p.Apply([]byte(tc.doc))
+			var patchErr *jsonpatch.Error
+			if !errors.As(err, &patchErr) {
+				t.Fatalf("expected Error: %v", err)
+			}
+			if patchErr.Index != tc.index {
+				t.Errorf("index: got %d want %d", patchErr.Index, tc.index)
+			}
+			if errors.Is(err, jsonpatch.ErrTestFailed) != tc.testFailed {
+				t.Errorf("test failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestPatch_TrailingData(t *testing.T) {
+	p := jsonpatch.Patch{{Op: jsonpatch.OpAdd, Path: "/-", Value: json.RawMessage(`1`)}}
+	for _, doc := range []string{`[0]]`, `[0]}`, `[0] []`} {
+		if _, err := p.Apply([]byte(doc)); err == nil {
+			t.Errorf("trailing data of %s is accepted", doc)
+		}
+	}
+	p = jsonpatch.Patch{{Op: jsonpatch.OpAdd, Path: "/-", Value: json.RawMessage(`{}}`)}}
+	if _, err := p.Apply([]byte(`[]`)); err == nil {
+		t.Errorf("trailing data of the value is accepted")
+	}
+}
+
+type config struct {
+	Name     string         `json:"name"`
+	Replicas int            `json:"replicas"`
+	Labels   map[string]int `json:"labels"`
+}
+
+func TestApplyTo(t *testing.T) {
+	c := config{Name: "api", Replicas: 1, Labels: map[string]int{"a": 1}}
+	p := jsonpatch.Patch{
+		{Op: jsonpatch.OpReplace, Path: "/replicas", Value: json.RawMessage(`3`)},
+		{Op: jsonpatch.OpAdd, Path: "/labels/b", Value: json.RawMessage(`2`)},
+	}
+	if err := jsonpatch.ApplyTo(&c, p); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if c.Replicas != 3 || c.Labels["b"] != 2 {
+		t.Errorf("unexpected config: %+v", c)
+	}
+	p = jsonpatch.Patch{
+		{Op: jsonpatch.OpReplace, Path: "/replicas", Value: json.RawMessage(`5`)},
+		{Op: jsonpatch.OpTest, Path: "/name", Value: json.RawMessage(`"web"`)},
+	}
+	if err := jsonpatch.ApplyTo(&c, p); !errors.Is(err, jsonpatch.ErrTestFailed) {
+		t.Fatalf("expected failed test: %v", err)
+	}
+	if c.Replicas != 3 {
+		t.Errorf("patch is not atomic: %+v", c)
+	}
+}
@@ -0,0 +1,71 @@
+package jsonpatch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pointer is a parsed JSON Pointer (RFC 6901). It consists of the unescaped
+// reference tokens. The empty pointer is referencing the whole document.
+type Pointer []string
+
+var (
+	unescaper = strings.NewReplacer("~1", "/", "~0", "~")
+	escaper   = strings.NewReplacer("~", "~0", "/", "~1")
+)
+
+// ParsePointer parses the JSON Pointer s.
+func ParsePointer(s string) (Pointer, error) {
+	if s == "" {
+		return Pointer{}, nil
+	}
+	if s[0] != '/' {
+		return nil, fmt.Errorf("pointer must be empty or start with /: %q", s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, token := range tokens {
+		for j := range len(token) {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("pointer contains invalid escape: %q", s)
+			}
+		}
+		tokens[i] = unescaper.Replace(token)
+	}
+	return Pointer(tokens), nil
+}
+
+func (p Pointer) String() string {
+	var b strings.Builder
+	for _, token := range p {
+		b.WriteByte('/')
+		b.WriteString(escaper.Replace(token))
+	}
+	return b.String()
+}
+
+// IsPrefixOf reports whether p is a proper prefix of other.
+func (p Pointer) IsPrefixOf(other Pointer) bool {
+	if len(p) >= len(other) {
+		return false
+	}
+	for i := range p {
+		if p[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex parses the reference token of an array element. Leading zeros
+// are not allowed.
+func arrayIndex(token string, length int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index: %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i >= length {
+		return 0, fmt.Errorf("array index out of bounds: %s", token)
+	}
+	return i, nil
+}
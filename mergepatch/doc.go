@@ -0,0 +1,3 @@
+// Package mergepatch implements JSON Merge Patch as defined in RFC 7386. It
+// contains the helpers used by the patch types generated by nuage.
+package mergepatch
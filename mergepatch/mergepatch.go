@@ -0,0 +1,198 @@
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+var null = []byte("null")
+
+// Field is a member of a patch document. It distinguishes between an absent
+// member, which leaves the target untouched, a member with the value null,
+// which deletes the target, and a member with a value replacing the target.
+type Field[T any] struct {
+	Value T
+
+	// Present reports whether the member is present in the patch document
+	Present bool
+
+	// Null reports whether the value of the member is null
+	Null bool
+}
+
+// Value returns a present Field with the value v.
+func Value[T any](v T) Field[T] {
+	return Field[T]{Value: v, Present: true}
+}
+
+// Null returns a present Field with the value null.
+func Null[T any]() Field[T] {
+	return Field[T]{Present: true, Null: true}
+}
+
+// UnmarshalJSON is only called for present members.
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	var zero T
+	f.Value, f.Present, f.Null = zero, true, bytes.Equal(data, null)
+	if f.Null {
+		return nil
+	}
+	return json.Unmarshal(data, &f.Value)
+}
+
+func (f Field[T]) MarshalJSON() ([]byte, error) {
+	if f.Null || !f.Present {
+		return null, nil
+	}
+	return json.Marshal(f.Value)
+}
+
+// IsZero reports whether the member is absent allowing to omit it using the
+// `omitzero` option of encoding/json.
+func (f Field[T]) IsZero() bool {
+	return !f.Present
+}
+
+// Apply replaces the value dst is pointing to. Null sets it to the zero value.
+func (f Field[T]) Apply(dst *T) {
+	switch {
+	case f.Null:
+		var zero T
+		*dst = zero
+	case f.Present:
+		*dst = f.Value
+	}
+}
+
+// QuotedField is a [Field] of a member whose value is encoded as a JSON string
+// as with the `string` option of encoding/json.
+type QuotedField[T any] struct {
+	Field[T]
+}
+
+// QuotedValue returns a present QuotedField with the value v.
+func QuotedValue[T any](v T) QuotedField[T] {
+	return QuotedField[T]{Field: Value(v)}
+}
+
+// UnmarshalJSON is only called for present members.
+func (f *QuotedField[T]) UnmarshalJSON(data []byte) error {
+	var zero T
+	f.Value, f.Present, f.Null = zero, true, bytes.Equal(data, null)
+	if f.Null {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(s), &f.Value)
+}
+
+func (f QuotedField[T]) MarshalJSON() ([]byte, error) {
+	if f.Null || !f.Present {
+		return null, nil
+	}
+	data, err := json.Marshal(f.Value)
+	if err != nil || bytes.Equal(data, null) {
+		return data, err
+	}
+	return json.Marshal(string(data))
+}
+
+// MergeMap merges the members of the patch f into the map dst is pointing
+// to. Members with the value null are deleted. The values of the other
+// members are replaced and not merged recursively.
+func MergeMap[M ~map[K]V, K ~string, V any](dst *M, f Field[map[K]Field[V]]) {
+	if f.Null {
+		*dst = nil
+		return
+	}
+	if !f.Present {
+		return
+	}
+	if *dst == nil {
+		*dst = make(M, len(f.Value))
+	}
+	for k, v := range f.Value {
+		if v.Null {
+			delete(*dst, k)
+			continue
+		}
+		(*dst)[k] = v.Value
+	}
+}
+
+// Apply applies the patch document to the JSON document target and returns
+// the result. The members of objects are ordered by their keys.
+func Apply(target, patch []byte) ([]byte, error) {
+	var t, p any
+	if len(bytes.TrimSpace(target)) > 0 {
+		if err := unmarshal(target, &t); err != nil {
+			return nil, err
+		}
+	}
+	if err := unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(Merge(t, p))
+}
+
+// ApplyTo applies the patch document to the JSON encoding of v. The result is
+// decoded into a new value replacing v if the patch is valid.
+func ApplyTo[T any](v *T, patch []byte) error {
+	if v == nil {
+		return errors.New("mergepatch: target is nil")
+	}
+	target, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data, err := Apply(target, patch)
+	if err != nil {
+		return err
+	}
+	var patched T
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return err
+	}
+	*v = patched
+	return nil
+}
+
+// Merge implements the MergePatch function of RFC 7386 for the decoded JSON
+// values target and patch. Objects are of type map[string]any. target is
+// modified in place.
+func Merge(target, patch any) any {
+	p, isObject := patch.(map[string]any)
+	if !isObject {
+		return patch
+	}
+	t, isObject := target.(map[string]any)
+	if !isObject {
+		t = make(map[string]any, len(p))
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = Merge(t[k], v)
+	}
+	return t
+}
+
+// unmarshal decodes data into v keeping the precision of numbers.
+func unmarshal(data []byte, v *any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if err := dec.Decode(&json.RawMessage{}); err != io.EOF {
+		return errors.New("mergepatch: unexpected data after JSON document")
+	}
+	return nil
+}
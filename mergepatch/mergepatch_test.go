@@ -0,0 +1,138 @@
+package mergepatch_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/naivary/nuage/mergepatch"
+)
+
+func TestApply(t *testing.T) {
+	// examples of RFC 7386 Appendix A
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{`{"n":12345678901234567890}`, `{}`, `{"n":12345678901234567890}`},
+	}
+	for _, tc := range tests {
+		got, err := mergepatch.Apply([]byte(tc.target), []byte(tc.patch))
+		if err != nil {
+			t.Errorf("Apply(%s, %s): %v", tc.target, tc.patch, err)
+			continue
+		}
+		if string(got) != tc.want {
+			t.Errorf("Apply(%s, %s): got %s want %s", tc.target, tc.patch, got, tc.want)
+		}
+	}
+	for _, patch := range []string{`{"a":1} {}`, `{"a":1}}`, `{"a":1}]`} {
+		if _, err := mergepatch.Apply([]byte(`{}`), []byte(patch)); err == nil {
+			t.Errorf("trailing data of %s is accepted", patch)
+		}
+	}
+	if _, err := mergepatch.Apply([]byte(`{}}`), []byte(`{}`)); err == nil {
+		t.Errorf("trailing data of the target is accepted")
+	}
+}
+
+type address struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+}
+
+type user struct {
+	Name    string            `json:"name"`
+	Email   string            `json:"email,omitempty"`
+	Address address           `json:"address"`
+	Labels  map[string]string `json:"labels"`
+}
+
+func TestApplyTo(t *testing.T) {
+	u := user{Name: "jane", Email: "jane@example.com", Address: address{Street: "main", City: "berlin"}}
+	patch := `{"email":null,"address":{"city":"paris"},"labels":{"a":"b"}}`
+	if err := mergepatch.ApplyTo(&u, []byte(patch)); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if u.Name != "jane" || u.Email != "" || u.Address != (address{Street: "main", City: "paris"}) || u.Labels["a"] != "b" {
+		t.Errorf("unexpected user: %+v", u)
+	}
+	if err := mergepatch.ApplyTo(&u, []byte(`{"name":1}`)); err == nil {
+		t.Errorf("invalid patch is accepted")
+	}
+	if u.Name != "jane" {
+		t.Errorf("user is modified by invalid patch: %+v", u)
+	}
+}
+
+type userPatch struct {
+	Name   mergepatch.Field[string]                              `json:"name,omitzero"`
+	Email  mergepatch.Field[string]                              `json:"email,omitzero"`
+	Labels mergepatch.Field[map[string]mergepatch.Field[string]] `json:"labels,omitzero"`
+}
+
+func TestField(t *testing.T) {
+	var p userPatch
+	if err := json.Unmarshal([]byte(`{"email":null,"labels":{"a":null,"c":"d"}}`), &p); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if p.Name.Present {
+		t.Errorf("absent member is present: %+v", p.Name)
+	}
+	if !p.Email.Present || !p.Email.Null {
+		t.Errorf("null member is not null: %+v", p.Email)
+	}
+	u := user{Name: "jane", Email: "jane@example.com", Labels: map[string]string{"a": "b"}}
+	p.Name.Apply(&u.Name)
+	p.Email.Apply(&u.Email)
+	mergepatch.MergeMap(&u.Labels, p.Labels)
+	if u.Name != "jane" || u.Email != "" || len(u.Labels) != 1 || u.Labels["c"] != "d" {
+		t.Errorf("unexpected user: %+v", u)
+	}
+	data, err := json.Marshal(userPatch{Name: mergepatch.Value("john"), Email: mergepatch.Null[string]()})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if want := `{"name":"john","email":null}`; string(data) != want {
+		t.Errorf("marshal: got %s want %s", data, want)
+	}
+}
+
+func TestQuotedField(t *testing.T) {
+	type patch struct {
+		Score mergepatch.QuotedField[float64] `json:"score,omitzero"`
+		Rank  mergepatch.QuotedField[*int]    `json:"rank,omitzero"`
+		Name  mergepatch.QuotedField[string]  `json:"name,omitzero"`
+	}
+	var p patch
+	if err := json.Unmarshal([]byte(`{"score":"1.5","rank":null,"name":"\"jane\""}`), &p); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if p.Score.Value != 1.5 || !p.Rank.Null || p.Name.Value != "jane" {
+		t.Errorf("unexpected patch: %+v", p)
+	}
+	if err := json.Unmarshal([]byte(`{"score":1.5}`), &p); err == nil {
+		t.Errorf("unquoted value is accepted")
+	}
+	rank := 2
+	data, err := json.Marshal(patch{Score: mergepatch.QuotedValue(1.5), Rank: mergepatch.QuotedValue(&rank)})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if want := `{"score":"1.5","rank":"2"}`; string(data) != want {
+		t.Errorf("marshal: got %s want %s", data, want)
+	}
+}
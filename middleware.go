@@ -0,0 +1,70 @@
+package nuage
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// NextFunc serves the request of ctx. A returned error is rendered as a
+// problem details response. If the error is an [HTTPError] it is rendered as
+// is otherwise it is sanitized to [ErrInternal].
+type NextFunc func(ctx *Context, w http.ResponseWriter, r *http.Request) error
+
+// Middleware wraps next to run code around the handling of a request. The
+// resolved operation is available through [Context.Operation]. A middleware
+// short-circuits the chain by returning an error without calling next.
+type Middleware func(next NextFunc) NextFunc
+
+// Use adds mws to the middlewares of n. Middlewares added to the root Nuage
+// are applied to all operations, middlewares added to a group only to the
+// operations of the group.
+//
+// Middlewares are called in the following order: global middlewares, group
+// middlewares from the outermost to the innermost group, the enforcement of
+// the security requirements of the operation and the middlewares passed to
+// [Handle]. Within each level they are called in the order they were added.
+// Middlewares have to be added before the first request is served.
+func (n *Nuage) Use(mws ...Middleware) {
+	n.middlewares = append(n.middlewares, mws...)
+}
+
+// Group returns a new group of n with mws as its middlewares. Operations
+// registered on the group using [Handle] are served by n but additionally
+// pass through the middlewares of the group.
+func (n *Nuage) Group(mws ...Middleware) *Nuage {
+	return &Nuage{
+		state:       n.state,
+		parent:      n,
+		middlewares: slices.Clone(mws),
+	}
+}
+
+// chain returns all middlewares applying to operations of n ordered from the
+// outermost to the innermost.
+func (n *Nuage) chain() []Middleware {
+	if n.parent == nil {
+		return n.middlewares
+	}
+	return append(slices.Clone(n.parent.chain()), n.middlewares...)
+}
+
+// endpoint returns the handler serving an operation registered on n. The
+// middleware chain is built when serving the first request allowing
+// middlewares to be added after the registration of the operation.
+func (n *Nuage) endpoint(serve NextFunc, mws []Middleware) http.Handler {
+	next := sync.OnceValue(func() NextFunc {
+		// the chain of the root is not cloned and must not be appended to
+		chain := slices.Concat(n.chain(), []Middleware{n.security}, mws)
+		for _, mw := range slices.Backward(chain) {
+			serve = mw(serve)
+		}
+		return serve
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewCtx(r)
+		if err := next()(ctx, w, ctx.Request()); err != nil {
+			writeError(w, ctx.Request(), err)
+		}
+	})
+}
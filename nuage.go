@@ -1,13 +1,169 @@
 package nuage
 
-import "net/http"
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/naivary/nuage/openapi"
+)
 
 type Nuage struct {
+	*state
+
+	// parent is the Nuage the group was created from. It is nil for the
+	// root created using [New] or [NewWithConfig].
+	parent *Nuage
+
+	// middlewares of the group. The middlewares of the root are applied to
+	// all operations.
+	middlewares []Middleware
+}
+
+// state is shared between a Nuage and all of its groups.
+type state struct {
 	mux *http.ServeMux
+
+	cfg *Config
+
+	// logger is the logger of the framework writing structured records to
+	// stdout.
+	logger *slog.Logger
+
+	// doc is the OpenAPI document assembled from all registered operations.
+	doc *openapi.OpenAPI
+
+	// operationIDs contains all operation ids which are already registered
+	// to guarantee their uniqueness across the whole API.
+	operationIDs map[string]struct{}
+
+	shutdownHooks []ShutdownHook
+
+	// authenticators of the registered security schemes by name.
+	authenticators map[string]Authenticator
+
+	// schemaNames are the names of the component schemas by the type they
+	// were inferred from.
+	schemaNames map[reflect.Type]string
+
+	// patchSchemaNames are the names of the component schemas of JSON Merge
+	// Patch documents by the type of the patched resource.
+	patchSchemaNames map[reflect.Type]string
+
+	// renderDocument renders the OpenAPI document on the first request for
+	// it. The document is expected to be complete once serving started.
+	renderDocument func() (*renderedDocument, error)
 }
 
+// New returns a Nuage configured by the environment variables as described in
+// [ConfigFromEnv].
 func New() (*Nuage, error) {
-	return &Nuage{
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewWithConfig(cfg)
+}
+
+// NewWithConfig returns a Nuage configured by cfg.
+func NewWithConfig(cfg *Config) (*Nuage, error) {
+	if cfg == nil {
+		return nil, errors.New("config is nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	n := &Nuage{state: &state{
 		mux: http.NewServeMux(),
-	}, nil
+		cfg: cfg.clone(),
+		logger: slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+			Level: cfg.LogLevel,
+		})),
+		doc: &openapi.OpenAPI{
+			Version:           openapi.OpenAPIVersion,
+			Info:              &openapi.Info{},
+			JSONSchemaDialect: openapi.JSONSchemaDialect,
+			Paths:             make(map[string]*openapi.PathItem),
+		},
+		operationIDs:     make(map[string]struct{}),
+		authenticators:   make(map[string]Authenticator),
+		schemaNames:      make(map[reflect.Type]string),
+		patchSchemaNames: make(map[reflect.Type]string),
+	}}
+	n.renderDocument = sync.OnceValues(n.render)
+	if err := n.serveDocument(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// register adds the operation to the OpenAPI document of n and registers the
+// handler for the pattern of the operation.
+func (n *Nuage) register(op *openapi.Operation, handler http.Handler) error {
+	method, path, err := parsePattern(op.Pattern)
+	if err != nil {
+		return err
+	}
+	if op.OperationID != "" {
+		if _, isDefined := n.operationIDs[op.OperationID]; isDefined {
+			return fmt.Errorf("operation id %q is already registered", op.OperationID)
+		}
+	}
+	// the path item is only updated after the handler was successfully
+	// registered to keep the document consistent with the mux.
+	item := openapi.PathItem{}
+	if existing, isDefined := n.doc.Paths[path]; isDefined {
+		item = *existing
+	}
+	if err := setOperation(&item, method, op); err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	if err := handle(n.mux, op.Pattern, handler); err != nil {
+		return err
+	}
+	n.doc.Paths[path] = &item
+	if op.OperationID != "" {
+		n.operationIDs[op.OperationID] = struct{}{}
+	}
+	return nil
+}
+
+// handle registers handler for pattern on mux. Conflicting patterns are
+// returned as an error instead of panicking.
+func handle(mux *http.ServeMux, pattern string, handler http.Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	mux.Handle(pattern, handler)
+	return nil
+}
+
+// Logger returns the logger of n writing structured records to stdout.
+func (n *Nuage) Logger() *slog.Logger {
+	return n.logger
+}
+
+// Document returns the OpenAPI document assembled from the registered
+// operations and security schemes. It may be extended for further
+// customization but must not be modified while serving requests.
+func (n *Nuage) Document() *openapi.OpenAPI {
+	return n.doc
+}
+
+// Config returns a copy of the configuration of n.
+func (n *Nuage) Config() Config {
+	return *n.cfg.clone()
+}
+
+func (n *Nuage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if n.cors(w, r) {
+		return
+	}
+	n.mux.ServeHTTP(w, r)
 }
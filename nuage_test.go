@@ -0,0 +1,376 @@
+package nuage_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/naivary/nuage"
+	"github.com/naivary/nuage/openapi"
+)
+
+type emptyRequest struct{}
+
+func (r *emptyRequest) Decode(req *http.Request) error { return nil }
+
+func noop(ctx *nuage.Context, r *emptyRequest) (struct{}, error) {
+	return struct{}{}, nil
+}
+
+func TestHandle(t *testing.T) {
+	tests := []struct {
+		name    string
+		ops     []*openapi.Operation
+		isValid bool
+	}{
+		{
+			name: "different methods on same path",
+			ops: []*openapi.Operation{
+				{Pattern: "GET /users/{id}", OperationID: "getUser"},
+				{Pattern: "DELETE /users/{id}", OperationID: "deleteUser"},
+			},
+			isValid: true,
+		},
+		{
+			name: "duplicate method and path",
+			ops: []*openapi.Operation{
+				{Pattern: "GET /users", OperationID: "listUsers"},
+				{Pattern: "GET /users", OperationID: "listUsersAgain"},
+			},
+		},
+		{
+			name: "duplicate operation id",
+			ops: []*openapi.Operation{
+				{Pattern: "GET /users", OperationID: "users"},
+				{Pattern: "POST /users", OperationID: "users"},
+			},
+		},
+		{
+			name: "missing method",
+			ops: []*openapi.Operation{
+				{Pattern: "/users", OperationID: "users"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := nuage.New()
+			if err != nil {
+				t.Fatalf("new: %v", err)
+			}
+			for _, op := range tc.ops {
+				err = nuage.Handle(n, noop, op)
+				if err != nil {
+					break
+				}
+			}
+			if tc.isValid && err != nil {
+				t.Errorf("expected no error: %v", err)
+			}
+			if !tc.isValid && err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	var calls []string
+	record := func(name string) nuage.Middleware {
+		return func(next nuage.NextFunc) nuage.NextFunc {
+			return func(ctx *nuage.Context, w http.ResponseWriter, r *http.Request) error {
+				if ctx.Operation() == nil {
+					t.Errorf("%s: operation is not available", name)
+				}
+				calls = append(calls, name)
+				return next(ctx, w, r)
+			}
+		}
+	}
+	deny := func(next nuage.NextFunc) nuage.NextFunc {
+		return func(ctx *nuage.Context, w http.ResponseWriter, r *http.Request) error {
+			return nuage.ErrUnauthorized
+		}
+	}
+	group := n.Group(record("group"))
+	err = nuage.Handle(group, noop, &openapi.Operation{Pattern: "GET /allowed"}, record("handle"))
+	if err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	err = nuage.Handle(group, noop, &openapi.Operation{Pattern: "GET /denied"}, deny)
+	if err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	// global middlewares added after the registration are still applied
+	n.Use(record("global"))
+
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/allowed", nil))
+	if got, want := strings.Join(calls, ","), "global,group,handle"; got != want {
+		t.Errorf("order: got %s want %s", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	n.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/denied", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status: got %d want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != nuage.ContentTypeHTTPError {
+		t.Errorf("content type: got %s", ct)
+	}
+}
+
+func TestMiddleware_Concurrent(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	header := func(value string) nuage.Middleware {
+		return func(next nuage.NextFunc) nuage.NextFunc {
+			return func(ctx *nuage.Context, w http.ResponseWriter, r *http.Request) error {
+				w.Header().Add("X-Middleware", value)
+				return next(ctx, w, r)
+			}
+		}
+	}
+	// the global middlewares have spare capacity which must not be shared
+	// by the chains of the operations
+	n.Use(header("global"), header("global"))
+	n.Use(header("global"))
+	paths := []string{"/one", "/two"}
+	for _, path := range paths {
+		err = nuage.Handle(n, noop, &openapi.Operation{Pattern: "GET " + path}, header(path))
+		if err != nil {
+			t.Fatalf("handle: %v", err)
+		}
+	}
+	var wg sync.WaitGroup
+	for i := range 32 {
+		path := paths[i%len(paths)]
+		wg.Go(func() {
+			rec := httptest.NewRecorder()
+			n.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			got := strings.Join(rec.Header().Values("X-Middleware"), ",")
+			if want := "global,global,global," + path; got != want {
+				t.Errorf("%s: got %s want %s", path, got, want)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestSecurity(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	// token authenticates requests using the scopes listed in the header
+	token := nuage.AuthenticatorFunc(func(ctx *nuage.Context, r *http.Request) (*nuage.Principal, error) {
+		scopes, isSet := r.Header["X-Scopes"]
+		if !isSet {
+			return nil, nuage.ErrUnauthorized.WithHeader("WWW-Authenticate", "Bearer")
+		}
+		return &nuage.Principal{Subject: "jane", Scopes: strings.Fields(scopes[0])}, nil
+	})
+	internal := nuage.AuthenticatorFunc(func(ctx *nuage.Context, r *http.Request) (*nuage.Principal, error) {
+		if r.Header.Get("X-Internal") == "" {
+			return nil, nuage.ErrUnauthorized
+		}
+		return &nuage.Principal{Subject: "cron"}, nil
+	})
+	schemes := map[string]nuage.Authenticator{"token": token, "internal": internal}
+	for name, authn := range schemes {
+		err := n.RegisterSecurityScheme(name, &openapi.SecurityScheme{Type: openapi.SecurityTypeOAuth2}, authn)
+		if err != nil {
+			t.Fatalf("register security scheme: %v", err)
+		}
+	}
+	var principal *nuage.Principal
+	hl := func(ctx *nuage.Context, r *emptyRequest) (nuage.NoContent, error) {
+		principal = ctx.Principal()
+		return nuage.NoContent{}, nil
+	}
+	op := &openapi.Operation{
+		Pattern: "DELETE /users/{id}",
+		Security: []openapi.SecurityRequirement{
+			{"token": {"users:write"}},
+			{"internal": {}},
+		},
+	}
+	if err := nuage.Handle(n, hl, op); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	err = nuage.Handle(n, hl, &openapi.Operation{
+		Pattern:  "GET /users",
+		Security: []openapi.SecurityRequirement{{"unknown": {}}},
+	})
+	if err == nil {
+		t.Errorf("expected an error for an unregistered security scheme")
+	}
+
+	tests := []struct {
+		name    string
+		header  map[string]string
+		status  int
+		subject string
+	}{
+		{name: "unauthenticated", status: http.StatusUnauthorized},
+		{name: "missing scope", header: map[string]string{"X-Scopes": "users:read"}, status: http.StatusForbidden},
+		{name: "scope granted", header: map[string]string{"X-Scopes": "users:write"}, status: http.StatusNoContent, subject: "jane"},
+		{name: "alternative requirement", header: map[string]string{"X-Internal": "1"}, status: http.StatusNoContent, subject: "cron"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			principal = nil
+			req := httptest.NewRequest(http.MethodDelete, "/users/1", nil)
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			n.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("status: got %d want %d: %s", rec.Code, tc.status, rec.Body.String())
+			}
+			if tc.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("challenge is missing")
+			}
+			if tc.subject != "" && (principal == nil || principal.Subject != tc.subject) {
+				t.Errorf("principal: got %+v want subject %s", principal, tc.subject)
+			}
+		})
+	}
+}
+
+func TestRegisterAPIKey(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	store := nuage.NewMemoryAPIKeyStore(map[string]*nuage.Principal{
+		"s3cr3t": {Subject: "billing-service"},
+	})
+	locations := map[openapi.ParamIn]func(r *http.Request, key string){
+		openapi.ParamInHeader: func(r *http.Request, key string) { r.Header.Set("X-Api-Key", key) },
+		openapi.ParamInQuery:  func(r *http.Request, key string) { r.URL.RawQuery = "api_key=" + key },
+		openapi.ParamInCookie: func(r *http.Request, key string) { r.AddCookie(&http.Cookie{Name: "api_key", Value: key}) },
+	}
+	for in := range locations {
+		name := "api_key"
+		if in == openapi.ParamInHeader {
+			name = "X-Api-Key"
+		}
+		if err := n.RegisterAPIKey(in.String(), in, name, store); err != nil {
+			t.Fatalf("register api key: %v", err)
+		}
+		hl := func(ctx *nuage.Context, r *emptyRequest) (nuage.NoContent, error) {
+			if ctx.Principal().Subject != "billing-service" {
+				t.Errorf("unexpected principal: %+v", ctx.Principal())
+			}
+			return nuage.NoContent{}, nil
+		}
+		op := &openapi.Operation{
+			Pattern:  "GET /" + in.String(),
+			Security: []openapi.SecurityRequirement{{in.String(): {}}},
+		}
+		if err := nuage.Handle(n, hl, op); err != nil {
+			t.Fatalf("handle: %v", err)
+		}
+	}
+	for in, setKey := range locations {
+		for key, status := range map[string]int{"s3cr3t": http.StatusNoContent, "wrong": http.StatusUnauthorized} {
+			req := httptest.NewRequest(http.MethodGet, "/"+in.String(), nil)
+			setKey(req, key)
+			rec := httptest.NewRecorder()
+			n.ServeHTTP(rec, req)
+			if rec.Code != status {
+				t.Errorf("%s: status: got %d want %d", in, rec.Code, status)
+			}
+		}
+	}
+	if scheme := n.Document().Components.SecuritySchemes["header"]; scheme.Name != "X-Api-Key" {
+		t.Errorf("security scheme is not documented: %+v", scheme)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	cfg := nuage.DefaultConfig()
+	cfg.CORSOrigins = []string{"https://example.com"}
+	n, err := nuage.NewWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	for _, pattern := range []string{"GET /users/{id}", "DELETE /users/{id}"} {
+		if err := nuage.Handle(n, noop, &openapi.Operation{Pattern: pattern}); err != nil {
+			t.Fatalf("handle: %v", err)
+		}
+	}
+	tests := []struct {
+		name    string
+		origin  string
+		method  string
+		headers string
+
+		allowOrigin  string
+		allowMethods string
+		allowHeaders string
+	}{
+		{
+			name:         "registered method",
+			origin:       "https://example.com",
+			method:       http.MethodDelete,
+			headers:      "content-type, x-request-id",
+			allowOrigin:  "https://example.com",
+			allowMethods: "GET, HEAD, DELETE",
+			allowHeaders: "content-type, x-request-id",
+		},
+		{
+			name:         "unregistered method",
+			origin:       "https://example.com",
+			method:       http.MethodPut,
+			allowOrigin:  "https://example.com",
+			allowMethods: "",
+		},
+		{
+			name:         "unknown headers",
+			origin:       "https://example.com",
+			method:       http.MethodGet,
+			headers:      "X-Custom, Authorization",
+			allowOrigin:  "https://example.com",
+			allowMethods: "GET, HEAD, DELETE",
+			allowHeaders: "Authorization",
+		},
+		{
+			name:   "unknown origin",
+			origin: "https://evil.com",
+			method: http.MethodDelete,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/users/1", nil)
+			req.Header.Set("Origin", tc.origin)
+			req.Header.Set("Access-Control-Request-Method", tc.method)
+			if tc.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tc.headers)
+			}
+			rec := httptest.NewRecorder()
+			n.ServeHTTP(rec, req)
+			header := rec.Header()
+			if got := header.Get("Access-Control-Allow-Origin"); got != tc.allowOrigin {
+				t.Errorf("allow origin: got %q want %q", got, tc.allowOrigin)
+			}
+			if got := header.Get("Access-Control-Allow-Methods"); got != tc.allowMethods {
+				t.Errorf("allow methods: got %q want %q", got, tc.allowMethods)
+			}
+			if got := header.Get("Access-Control-Allow-Headers"); got != tc.allowHeaders {
+				t.Errorf("allow headers: got %q want %q", got, tc.allowHeaders)
+			}
+		})
+	}
+}
@@ -0,0 +1,452 @@
+package nuage
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/naivary/nuage/internal/jwtutil"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// defaultJWKSCacheDuration is the duration a fetched JSON Web Key Set is
+	// cached if the response is not defining a max-age.
+	defaultJWKSCacheDuration = time.Hour
+
+	// minJWKSRefreshInterval is the minimum interval between two fetches of
+	// the JSON Web Key Set.
+	minJWKSRefreshInterval = time.Minute
+
+	// jwksFetchTimeout limits the duration of a fetch of the JSON Web Key Set.
+	jwksFetchTimeout = 10 * time.Second
+
+	// maxDiscoveryResponseSize limits the size of the documents fetched from
+	// the OpenID Provider.
+	maxDiscoveryResponseSize = 1 * MiB / Byte
+)
+
+var (
+	ErrKeyNotFound = errors.New("signing key not found")
+
+	// ErrKeyAlgorithm is returned if the signing key does not support the
+	// signing algorithm of a token.
+	ErrKeyAlgorithm = errors.New("signing key does not support the algorithm")
+)
+
+// errInvalidToken is wrapped by all errors of tokens failing verification to
+// distinguish them from failures of the provider.
+var errInvalidToken = errors.New("invalid token")
+
+// OIDCProvider provides the signing keys of an OpenID Provider. It is
+// responsible for caching and rotating the keys.
+type OIDCProvider interface {
+	// Issuer returns the issuer identifier of the OpenID Provider.
+	Issuer() string
+
+	// Key returns the public key identified by kid to verify signatures of
+	// the algorithm alg. If kid is empty and the provider has exactly one key
+	// it is returned. [ErrKeyNotFound] is returned if the key is unknown and
+	// [ErrKeyAlgorithm] if the key does not support alg.
+	Key(ctx context.Context, kid, alg string) (crypto.PublicKey, error)
+}
+
+type oidcProvider struct {
+	issuer  string
+	jwksURI string
+	client  *http.Client
+
+	// group deduplicates concurrent fetches of the keys
+	group singleflight.Group
+
+	mu   sync.RWMutex
+	keys map[string]*oidcKey
+	// err is the error of the last fetch
+	err         error
+	expiresAt   time.Time
+	attemptedAt time.Time
+}
+
+// oidcKey is a signing key of the JSON Web Key Set of a provider.
+type oidcKey struct {
+	jwk jwtutil.JWK
+	key crypto.PublicKey
+}
+
+// DiscoverOIDC returns the provider of issuer using OpenID Connect Discovery
+// 1.0. The metadata is fetched from the well-known configuration endpoint of
+// issuer. If client is nil [http.DefaultClient] is used.
+func DiscoverOIDC(ctx context.Context, issuer string, client *http.Client) (OIDCProvider, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	endpoint := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	var metadata struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if _, err := fetchJSON(ctx, client, endpoint, &metadata); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if metadata.Issuer != issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", metadata.Issuer, issuer)
+	}
+	if metadata.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: jwks_uri is missing")
+	}
+	return &oidcProvider{
+		issuer:  issuer,
+		jwksURI: metadata.JWKSURI,
+		client:  client,
+	}, nil
+}
+
+func (p *oidcProvider) Issuer() string {
+	return p.issuer
+}
+
+// Key returns the cached key. The keys are fetched again if they are expired
+// or kid is unknown as the keys might have been rotated by the provider. The
+// expired keys are served if the fetch fails.
+func (p *oidcProvider) Key(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	k, isFound, isExpired := p.key(kid)
+	if isFound && !isExpired {
+		return k.verify(alg)
+	}
+	if err := p.refresh(ctx); err != nil && !isFound {
+		return nil, err
+	}
+	if k, isFound, _ = p.key(kid); isFound {
+		return k.verify(alg)
+	}
+	return nil, ErrKeyNotFound
+}
+
+// key looks up the key identified by kid and reports whether the keys are
+// expired.
+func (p *oidcProvider) key(kid string) (*oidcKey, bool, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	isExpired := time.Now().After(p.expiresAt)
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, true, isExpired
+		}
+	}
+	k, isFound := p.keys[kid]
+	return k, isFound, isExpired
+}
+
+// verify returns the public key of k if it supports alg.
+func (k *oidcKey) verify(alg string) (crypto.PublicKey, error) {
+	if !k.jwk.Supports(alg) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyAlgorithm, alg)
+	}
+	return k.key, nil
+}
+
+// refresh fetches the keys. Unless the keys are expired they are fetched at
+// most once per [minJWKSRefreshInterval] and the error of the last fetch is
+// returned otherwise. Failed fetches are retried after the same interval.
+// Concurrent callers share one fetch which is not canceled if a caller gives
+// up.
+func (p *oidcProvider) refresh(ctx context.Context) error {
+	ch := p.group.DoChan("jwks", func() (any, error) {
+		p.mu.RLock()
+		isRecent := time.Since(p.attemptedAt) < minJWKSRefreshInterval
+		// expired keys are fetched again unless the last fetch failed
+		isDue := p.err == nil && time.Now().After(p.expiresAt)
+		err := p.err
+		p.mu.RUnlock()
+		if isRecent && !isDue {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jwksFetchTimeout)
+		defer cancel()
+		keys, maxAge, err := p.fetchKeys(ctx)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.attemptedAt = time.Now()
+		p.err = err
+		if err != nil {
+			return nil, err
+		}
+		p.keys = keys
+		p.expiresAt = p.attemptedAt.Add(maxAge)
+		return nil, nil
+	})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-ch:
+		return res.Err
+	}
+}
+
+// fetchKeys fetches the JSON Web Key Set of the provider and returns the keys
+// and the duration they may be cached. Keys which are not used for signatures
+// or can not be parsed are skipped.
+func (p *oidcProvider) fetchKeys(ctx context.Context) (map[string]*oidcKey, time.Duration, error) {
+	var jwks jwtutil.JWKS
+	maxAge, err := fetchJSON(ctx, p.client, p.jwksURI, &jwks)
+	if err != nil {
+		return nil, 0, fmt.Errorf("oidc jwks: %w", err)
+	}
+	keys := make(map[string]*oidcKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = &oidcKey{jwk: jwk, key: key}
+	}
+	if maxAge <= 0 {
+		maxAge = defaultJWKSCacheDuration
+	}
+	return keys, maxAge, nil
+}
+
+// fetchJSON fetches the JSON document at url and decodes it into v. The
+// max-age of the Cache-Control header of the response is returned.
+func fetchJSON(ctx context.Context, client *http.Client, url string, v any) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", ContentTypeJSON)
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status code %d from %s", res.StatusCode, url)
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, int64(maxDiscoveryResponseSize))).Decode(v); err != nil {
+		return 0, err
+	}
+	return maxAge(res.Header.Get("Cache-Control")), nil
+}
+
+func maxAge(cacheControl string) time.Duration {
+	for directive := range strings.SplitSeq(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
+
+// OIDCConfig is the configuration of an [OIDCVerifier].
+type OIDCConfig struct {
+	// Provider of the signing keys. Use [DiscoverOIDC] to discover the
+	// provider of an issuer.
+	Provider OIDCProvider
+
+	// Audience which has to be contained in the aud claim of a token.
+	Audience string
+
+	// Leeway is the tolerated clock skew when validating the exp and nbf
+	// claims.
+	Leeway time.Duration
+
+	// Realm is the protection space reported in the WWW-Authenticate header.
+	Realm string
+}
+
+// OIDCVerifier verifies bearer tokens issued by an OpenID Provider.
+type OIDCVerifier struct {
+	cfg OIDCConfig
+	now func() time.Time
+}
+
+func NewOIDCVerifier(cfg OIDCConfig) (*OIDCVerifier, error) {
+	if cfg.Provider == nil {
+		return nil, errors.New("oidc: provider is nil")
+	}
+	if cfg.Audience == "" {
+		return nil, errors.New("oidc: audience is empty")
+	}
+	return &OIDCVerifier{
+		cfg: cfg,
+		now: time.Now,
+	}, nil
+}
+
+// Verify verifies the signature and the registered claims of the JSON Web
+// Token raw and returns the principal identified by it. Errors of the
+// provider, e.g. the keys could not be fetched, are returned as is.
+func (v *OIDCVerifier) Verify(ctx context.Context, raw string) (*Principal, error) {
+	token, err := jwtutil.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidToken, err)
+	}
+	key, err := v.cfg.Provider.Key(ctx, token.Header.Kid, token.Header.Alg)
+	if errors.Is(err, ErrKeyNotFound) || errors.Is(err, ErrKeyAlgorithm) {
+		return nil, fmt.Errorf("%w: %v", errInvalidToken, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := token.Verify(key); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidToken, err)
+	}
+	claims := token.Claims
+	if iss, _ := claims["iss"].(string); iss != v.cfg.Provider.Issuer() {
+		return nil, fmt.Errorf("%w: issuer is invalid", errInvalidToken)
+	}
+	if !slices.Contains(stringOrList(claims["aud"]), v.cfg.Audience) {
+		return nil, fmt.Errorf("%w: audience is invalid", errInvalidToken)
+	}
+	now := v.now()
+	exp, err := numericDate(claims, "exp")
+	if err != nil || exp.IsZero() {
+		return nil, fmt.Errorf("%w: expiration time is invalid", errInvalidToken)
+	}
+	if !now.Before(exp.Add(v.cfg.Leeway)) {
+		return nil, fmt.Errorf("%w: token is expired", errInvalidToken)
+	}
+	nbf, err := numericDate(claims, "nbf")
+	if err != nil {
+		return nil, fmt.Errorf("%w: not before is invalid", errInvalidToken)
+	}
+	if now.Add(v.cfg.Leeway).Before(nbf) {
+		return nil, fmt.Errorf("%w: token is not valid yet", errInvalidToken)
+	}
+	sub, _ := claims["sub"].(string)
+	scopes := strings.Fields(stringClaim(claims, "scope"))
+	if len(scopes) == 0 {
+		scopes = stringOrList(claims["scp"])
+	}
+	return &Principal{
+		Subject: sub,
+		Scopes:  scopes,
+		Claims:  claims,
+	}, nil
+}
+
+var _ Authenticator = (*OIDCVerifier)(nil)
+
+// Authenticate authenticates r using the bearer token in the Authorization
+// header. Requests without a valid token are answered with [ErrUnauthorized]
+// and a WWW-Authenticate challenge as defined in RFC 6750.
+func (v *OIDCVerifier) Authenticate(ctx *Context, r *http.Request) (*Principal, error) {
+	raw, hasToken := bearerToken(r)
+	if !hasToken {
+		return nil, ErrUnauthorized.WithHeader("WWW-Authenticate", v.challenge(""))
+	}
+	p, err := v.Verify(ctx, raw)
+	if err != nil && !errors.Is(err, errInvalidToken) {
+		return nil, err
+	}
+	if err != nil {
+		ctx.Logger().DebugContext(ctx, "bearer token is invalid", "err", err)
+		return nil, ErrUnauthorized.
+			WithHeader("WWW-Authenticate", v.challenge(err.Error())).
+			WithDetail("bearer token is invalid")
+	}
+	return p, nil
+}
+
+// Middleware returns a middleware authenticating every request as described
+// in [OIDCVerifier.Authenticate]. To authenticate only the operations
+// requiring it register the verifier as the [Authenticator] of an
+// openIdConnect security scheme instead.
+func (v *OIDCVerifier) Middleware() Middleware {
+	return func(next NextFunc) NextFunc {
+		return func(ctx *Context, w http.ResponseWriter, r *http.Request) error {
+			p, err := v.Authenticate(ctx, r)
+			if err != nil {
+				return err
+			}
+			ctx.SetPrincipal(p)
+			return next(ctx, w, r)
+		}
+	}
+}
+
+// challenge returns the value of the WWW-Authenticate header. If desc is not
+// empty the token is reported as invalid.
+func (v *OIDCVerifier) challenge(desc string) string {
+	params := make([]string, 0, 3)
+	if v.cfg.Realm != "" {
+		params = append(params, fmt.Sprintf("realm=%q", v.cfg.Realm))
+	}
+	if desc != "" {
+		params = append(params, `error="invalid_token"`, fmt.Sprintf("error_description=%q", desc))
+	}
+	if len(params) == 0 {
+		return "Bearer"
+	}
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+// bearerToken returns the bearer token of the Authorization header of r.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func stringClaim(claims map[string]any, name string) string {
+	s, _ := claims[name].(string)
+	return s
+}
+
+// stringOrList returns the value of a claim which is either a single string
+// or a list of strings.
+func stringOrList(v any) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []any:
+		list := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, isString := item.(string); isString {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+// numericDate returns the time of the NumericDate claim name. The zero time is
+// returned if the claim is not set.
+func numericDate(claims map[string]any, name string) (time.Time, error) {
+	v, isSet := claims[name]
+	if !isSet {
+		return time.Time{}, nil
+	}
+	num, isNumber := v.(json.Number)
+	if !isNumber {
+		return time.Time{}, fmt.Errorf("%s is not a number", name)
+	}
+	seconds, err := num.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(seconds), 0), nil
+}
@@ -0,0 +1,265 @@
+package nuage_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/naivary/nuage"
+	"github.com/naivary/nuage/openapi"
+)
+
+// issuer is an in-process stand-in of an OpenID Provider.
+type issuer struct {
+	*httptest.Server
+
+	key *rsa.PrivateKey
+	kid string
+
+	// maxAge is the max-age of the JSON Web Key Set
+	maxAge int
+	// fetches counts the fetches of the JSON Web Key Set
+	fetches atomic.Int32
+	// isDown lets the fetches of the JSON Web Key Set fail
+	isDown atomic.Bool
+}
+
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	iss := &issuer{key: key, kid: "key-1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   iss.URL,
+			"jwks_uri": iss.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		iss.fetches.Add(1)
+		if iss.isDown.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if iss.maxAge > 0 {
+			w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(iss.maxAge))
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": iss.kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	iss.Server = httptest.NewServer(mux)
+	t.Cleanup(iss.Close)
+	return iss
+}
+
+func (iss *issuer) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": iss.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, iss.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestOIDCVerifier_Middleware(t *testing.T) {
+	iss := newIssuer(t)
+	provider, err := nuage.DiscoverOIDC(context.Background(), iss.URL, iss.Client())
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	verifier, err := nuage.NewOIDCVerifier(nuage.OIDCConfig{
+		Provider: provider,
+		Audience: "api",
+		Realm:    "nuage",
+	})
+	if err != nil {
+		t.Fatalf("verifier: %v", err)
+	}
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	n.Use(verifier.Middleware())
+	var principal *nuage.Principal
+	hl := func(ctx *nuage.Context, r *emptyRequest) (nuage.NoContent, error) {
+		principal = ctx.Principal()
+		return nuage.NoContent{}, nil
+	}
+	if err := nuage.Handle(n, hl, &openapi.Operation{Pattern: "GET /me"}); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+
+	now := time.Now()
+	valid := map[string]any{
+		"iss":   iss.URL,
+		"aud":   "api",
+		"sub":   "jane",
+		"scope": "users:read users:write",
+		"exp":   now.Add(time.Hour).Unix(),
+		"nbf":   now.Add(-time.Minute).Unix(),
+	}
+	tests := []struct {
+		name      string
+		token     string
+		status    int
+		challenge string
+	}{
+		{
+			name:   "valid",
+			token:  iss.sign(t, valid),
+			status: http.StatusNoContent,
+		},
+		{
+			name:      "missing",
+			status:    http.StatusUnauthorized,
+			challenge: `Bearer realm="nuage"`,
+		},
+		{
+			name:      "expired",
+			token:     iss.sign(t, with(valid, "exp", now.Add(-time.Hour).Unix())),
+			status:    http.StatusUnauthorized,
+			challenge: `error="invalid_token"`,
+		},
+		{
+			name:      "wrong audience",
+			token:     iss.sign(t, with(valid, "aud", "other")),
+			status:    http.StatusUnauthorized,
+			challenge: `error="invalid_token"`,
+		},
+		{
+			name:      "wrong issuer",
+			token:     iss.sign(t, with(valid, "iss", "https://evil.example.com")),
+			status:    http.StatusUnauthorized,
+			challenge: `error="invalid_token"`,
+		},
+		{
+			name:      "tampered",
+			token:     tamper(iss.sign(t, valid)),
+			status:    http.StatusUnauthorized,
+			challenge: `error="invalid_token"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			principal = nil
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			n.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("status: got %d want %d: %s", rec.Code, tc.status, rec.Body.String())
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); !strings.Contains(got, tc.challenge) {
+				t.Errorf("challenge: got %q want %q", got, tc.challenge)
+			}
+			if tc.status != http.StatusNoContent {
+				return
+			}
+			if principal == nil || principal.Subject != "jane" || len(principal.Scopes) != 2 {
+				t.Errorf("unexpected principal: %+v", principal)
+			}
+		})
+	}
+}
+
+func TestOIDCProvider_Key(t *testing.T) {
+	iss := newIssuer(t)
+	provider, err := nuage.DiscoverOIDC(context.Background(), iss.URL, iss.Client())
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	// unknown keys are fetched once within the refresh interval
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Go(func() {
+			_, err := provider.Key(context.Background(), "unknown", "RS256")
+			if !errors.Is(err, nuage.ErrKeyNotFound) {
+				t.Errorf("unknown key: got %v want %v", err, nuage.ErrKeyNotFound)
+			}
+		})
+	}
+	wg.Wait()
+	if _, err := provider.Key(context.Background(), iss.kid, "RS256"); err != nil {
+		t.Errorf("key: %v", err)
+	}
+	if got := iss.fetches.Load(); got != 1 {
+		t.Errorf("fetches: got %d want 1", got)
+	}
+	for _, alg := range []string{"PS256", "ES256", "EdDSA", "none"} {
+		if _, err := provider.Key(context.Background(), iss.kid, alg); !errors.Is(err, nuage.ErrKeyAlgorithm) {
+			t.Errorf("%s: got %v want %v", alg, err, nuage.ErrKeyAlgorithm)
+		}
+	}
+}
+
+func TestOIDCProvider_Key_Stale(t *testing.T) {
+	iss := newIssuer(t)
+	iss.maxAge = 1
+	provider, err := nuage.DiscoverOIDC(context.Background(), iss.URL, iss.Client())
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	if _, err := provider.Key(context.Background(), iss.kid, "RS256"); err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	iss.isDown.Store(true)
+	time.Sleep(1100 * time.Millisecond)
+	// the expired keys are served while the provider is down and the failed
+	// fetch is not retried immediately
+	for range 2 {
+		if _, err := provider.Key(context.Background(), iss.kid, "RS256"); err != nil {
+			t.Errorf("stale key: %v", err)
+		}
+	}
+	if got := iss.fetches.Load(); got != 2 {
+		t.Errorf("fetches: got %d want 2", got)
+	}
+}
+
+func with(claims map[string]any, key string, value any) map[string]any {
+	c := make(map[string]any, len(claims))
+	for k, v := range claims {
+		c[k] = v
+	}
+	c[key] = value
+	return c
+}
+
+// tamper replaces the payload of the token keeping the original signature.
+func tamper(token string) string {
+	parts := strings.Split(token, ".")
+	payload, _ := json.Marshal(map[string]any{"sub": "admin"})
+	parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+	return strings.Join(parts, ".")
+}
@@ -20,7 +20,7 @@ const (
 	// It identifies the JSON Schema specification that schema definitions
 	// are expected to follow, and is typically referenced from the
 	// OpenAPI document's `jsonSchemaDialect` field.
-	JSONSchemaDialect = "https://json-schema.org/draft/2020-12/json-schema-core.html"
+	JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
 )
 
 type ParamIn string
@@ -74,11 +74,12 @@ const (
 
 type OpenAPI struct {
 	Version           string               `json:"openapi"`
-	Self              string               `json:"$self"`
+	Self              string               `json:"$self,omitempty"`
 	Info              *Info                `json:"info"`
 	JSONSchemaDialect string               `json:"jsonSchemaDialect,omitempty"`
 	Servers           []*Server            `json:"servers,omitempty"`
 	Paths             map[string]*PathItem `json:"paths"`
+	Components        *Components          `json:"components,omitempty"`
 }
 
 type Info struct {
@@ -115,9 +116,9 @@ type License struct {
 }
 
 type Components struct {
-	Schemas         map[string]*jsonschema.Schema
-	Responses       map[string]*Response
-	SecuritySchemes map[string]*SecurityScheme
+	Schemas         map[string]*jsonschema.Schema `json:"schemas,omitempty"`
+	Responses       map[string]*Response          `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme    `json:"securitySchemes,omitempty"`
 }
 
 type PathItem struct {
@@ -192,25 +193,25 @@ type MediaType struct {
 
 type SecurityScheme struct {
 	Type            SecurityType `json:"type"`
-	Description     string       `json:"description"`
-	Name            string       `json:"name"`
-	In              ParamIn      `json:"in"`
-	Scheme          string       `json:"scheme"`
-	BearerFormat    string       `json:"bearerFormat"`
-	Flows           *OAuthFlows  `json:"flows"`
-	OpenIDConnecURL string       `json:"openIdConnectUrl"`
+	Description     string       `json:"description,omitempty"`
+	Name            string       `json:"name,omitempty"`
+	In              ParamIn      `json:"in,omitempty"`
+	Scheme          string       `json:"scheme,omitempty"`
+	BearerFormat    string       `json:"bearerFormat,omitempty"`
+	Flows           *OAuthFlows  `json:"flows,omitempty"`
+	OpenIDConnecURL string       `json:"openIdConnectUrl,omitempty"`
 }
 
 type OAuthFlows struct {
-	Implicit          *OAuthFlow `json:"implicit"`
-	Password          *OAuthFlow `json:"password"`
-	ClientCredentials *OAuthFlow `json:"clientCredentials"`
-	AuthorizationCode *OAuthFlow `json:"authorizationCode"`
+	Implicit          *OAuthFlow `json:"implicit,omitempty"`
+	Password          *OAuthFlow `json:"password,omitempty"`
+	ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty"`
+	AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty"`
 }
 
 type OAuthFlow struct {
-	AuhtorizationURL string            `json:"authorizationUrl"`
-	TokenURL         string            `json:"tokenUrl"`
-	RefreshURL       string            `json:"refreshUrl"`
+	AuhtorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	RefreshURL       string            `json:"refreshUrl,omitempty"`
 	Scopes           map[string]string `json:"scopes"`
 }
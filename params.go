@@ -0,0 +1,51 @@
+package nuage
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/naivary/nuage/openapi"
+)
+
+// ParamError describes a parameter of the request which is invalid. It is
+// returned by the generated decoders as part of [ParamErrors].
+type ParamError struct {
+	// Name of the parameter
+	Name string `json:"name"`
+
+	// In is the location of the parameter
+	In openapi.ParamIn `json:"in"`
+
+	// Value is the raw value of the parameter. It is empty for missing
+	// parameters.
+	Value string `json:"value,omitempty"`
+
+	// Reason is a human-readable explanation why the parameter is invalid
+	Reason string `json:"reason"`
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("%s parameter %q %s", e.In, e.Name, e.Reason)
+}
+
+// ParamErrors are all invalid parameters of a request collected by the
+// generated decoders. They are rendered as [ErrParamInvalid] listing every
+// parameter in the `invalid-params` extension.
+type ParamErrors []*ParamError
+
+func (e ParamErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Contains reports whether e contains an error of the parameter name at the
+// location in.
+func (e ParamErrors) Contains(name string, in openapi.ParamIn) bool {
+	return slices.ContainsFunc(e, func(err *ParamError) bool {
+		return err.Name == name && err.In == in
+	})
+}
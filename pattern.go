@@ -0,0 +1,71 @@
+package nuage
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/naivary/nuage/openapi"
+)
+
+// parsePattern splits the pattern of an operation into its method and the
+// path as it is documented in the OpenAPI document. The pattern has to follow
+// the syntax of [http.ServeMux] and must always define the method.
+func parsePattern(pattern string) (string, string, error) {
+	method, rest, hasMethod := strings.Cut(strings.TrimSpace(pattern), " ")
+	if !hasMethod {
+		return "", "", fmt.Errorf("pattern %q: method is missing", pattern)
+	}
+	rest = strings.TrimLeft(rest, " \t")
+	i := strings.IndexByte(rest, '/')
+	if i < 0 {
+		return "", "", fmt.Errorf("pattern %q: path is missing", pattern)
+	}
+	// host is not part of the path in the OpenAPI document
+	path := rest[i:]
+	segments := strings.Split(path, "/")
+	for j, segment := range segments {
+		switch {
+		case segment == "{$}":
+			segments[j] = ""
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "...}"):
+			segments[j] = strings.TrimSuffix(segment, "...}") + "}"
+		}
+	}
+	return method, strings.Join(segments, "/"), nil
+}
+
+// setOperation sets op in item for the given method. An error is returned if
+// an operation is already defined for the method or the method is not
+// supported by OpenAPI.
+func setOperation(item *openapi.PathItem, method string, op *openapi.Operation) error {
+	var slot **openapi.Operation
+	switch method {
+	case http.MethodGet:
+		slot = &item.Get
+	case http.MethodPut:
+		slot = &item.Put
+	case http.MethodPost:
+		slot = &item.Post
+	case http.MethodDelete:
+		slot = &item.Delete
+	case http.MethodOptions:
+		slot = &item.Options
+	case http.MethodHead:
+		slot = &item.Head
+	case http.MethodPatch:
+		slot = &item.Patch
+	case http.MethodTrace:
+		slot = &item.Trace
+	case "QUERY":
+		slot = &item.Query
+	default:
+		return fmt.Errorf("method %q is not supported", method)
+	}
+	if *slot != nil {
+		return errors.New("operation is already registered")
+	}
+	*slot = op
+	return nil
+}
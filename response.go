@@ -0,0 +1,170 @@
+package nuage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/naivary/nuage/openapi"
+)
+
+// JSONEncoder is implemented by response models with a generated encoder
+// avoiding the reflection of encoding/json.
+type JSONEncoder interface {
+	// EncodeJSON writes the JSON encoding of the response model followed by
+	// a newline to w.
+	EncodeJSON(w io.Writer) error
+}
+
+// NoContent is the response model of operations which are not responding
+// with a body. Handlers returning NoContent are answered with the status code
+// 204 (No Content).
+type NoContent struct{}
+
+// maxPooledBufSize is the maximum capacity of a buffer which is returned to
+// the pool to avoid holding on to the memory of exceptionally large responses.
+const maxPooledBufSize = 64 * KiB / Byte
+
+var bufPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// writeResponse encodes res and writes it to w with the status code and
+// content type defined by op. The response is buffered before writing allowing
+// to respond with a problem if the encoding fails.
+func writeResponse[ResponseModel any](w http.ResponseWriter, r *http.Request, op *openapi.Operation, res ResponseModel) error {
+	if isNoContent[ResponseModel]() {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBufSize {
+			bufPool.Put(buf)
+		}
+	}()
+	if err := encodeJSON(buf, &res); err != nil {
+		NewCtx(r).Logger().ErrorContext(r.Context(), "response could not be encoded", "err", err)
+		return ErrJSONEncoding
+	}
+	w.Header().Set("Content-Type", responseContentType(op))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(responseStatusCode[ResponseModel](op))
+	w.Write(buf.Bytes())
+	return nil
+}
+
+// encodeJSON writes the JSON encoding of the value res is pointing to followed
+// by a newline to w. The generated encoder is preferred over encoding/json.
+func encodeJSON[ResponseModel any](w io.Writer, res *ResponseModel) error {
+	if enc, isEncoder := any(*res).(JSONEncoder); isEncoder {
+		return enc.EncodeJSON(w)
+	}
+	if enc, isEncoder := any(res).(JSONEncoder); isEncoder {
+		return enc.EncodeJSON(w)
+	}
+	return json.NewEncoder(w).Encode(res)
+}
+
+func isNoContent[ResponseModel any]() bool {
+	_, isNoContent := any(*new(ResponseModel)).(NoContent)
+	return isNoContent
+}
+
+func responseStatusCode[ResponseModel any](op *openapi.Operation) int {
+	if isNoContent[ResponseModel]() {
+		return http.StatusNoContent
+	}
+	if op == nil || op.ResponseStatusCode == 0 {
+		return http.StatusOK
+	}
+	return op.ResponseStatusCode
+}
+
+func responseContentType(op *openapi.Operation) string {
+	if op == nil || op.ResponseContentType == "" {
+		return ContentTypeJSON
+	}
+	return op.ResponseContentType
+}
+
+// documentResponse adds the response defined by op to the responses of op.
+func documentResponse[ResponseModel any](op *openapi.Operation, schema *jsonschema.Schema) {
+	op.ResponseStatusCode = responseStatusCode[ResponseModel](op)
+	desc := op.ResponseDesc
+	if desc == "" {
+		desc = http.StatusText(op.ResponseStatusCode)
+	}
+	res := &openapi.Response{
+		Description: desc,
+	}
+	if !isNoContent[ResponseModel]() {
+		op.ResponseContentType = responseContentType(op)
+		res.Content = map[string]*openapi.MediaType{
+			op.ResponseContentType: {Schema: schema},
+		}
+	}
+	if op.Responses == nil {
+		op.Responses = make(map[string]*openapi.Response, 1)
+	}
+	op.Responses[strconv.Itoa(op.ResponseStatusCode)] = res
+}
+
+// bodyAllowedForStatus reports whether a response with the given status code
+// is permitted to have a body as defined in RFC 9110.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// responseWriter records the status code and the number of bytes written as
+// the response body.
+type responseWriter struct {
+	http.ResponseWriter
+
+	status int
+	size   int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Status returns the status code of the response. If nothing has been
+// written yet it is 200 (OK) as it would be implicitly sent.
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Unwrap allows [http.ResponseController] to access the underlying
+// [http.ResponseWriter].
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,458 @@
+package nuage
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/naivary/nuage/jsonpatch"
+)
+
+// schemaRefPrefix is the prefix of references to component schemas.
+const schemaRefPrefix = "#/components/schemas/"
+
+var (
+	// pkgPathRe matches the import paths of the type arguments in the name
+	// of an instantiated generic type.
+	pkgPathRe = regexp.MustCompile(`[^\[\],*]*/`)
+
+	// invalidSchemaNameRe matches characters which are not allowed in the
+	// name of a component.
+	invalidSchemaNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+)
+
+// schemaRegistry infers the component schemas of an operation. The schemas
+// are staged and only added to the document once the operation is registered
+// to not leave schemas of failed registrations behind.
+type schemaRegistry struct {
+	// names are the names of the component schemas by the type they were
+	// inferred from.
+	names map[reflect.Type]string
+
+	// patchNames are the names of the component schemas of JSON Merge Patch
+	// documents by the type of the patched resource.
+	patchNames map[reflect.Type]string
+
+	// schemas are the component schemas by name
+	schemas map[string]*jsonschema.Schema
+}
+
+// stageSchemas returns a registry of the component schemas of n. The schemas
+// added to it are not visible to n until they are committed.
+func (n *Nuage) stageSchemas() *schemaRegistry {
+	r := &schemaRegistry{
+		names:      maps.Clone(n.schemaNames),
+		patchNames: maps.Clone(n.patchSchemaNames),
+	}
+	if n.doc.Components != nil {
+		r.schemas = maps.Clone(n.doc.Components.Schemas)
+	}
+	return r
+}
+
+// commitSchemas adds the schemas staged in r to the document of n.
+func (n *Nuage) commitSchemas(r *schemaRegistry) {
+	n.schemaNames, n.patchSchemaNames = r.names, r.patchNames
+	if len(r.schemas) > 0 {
+		n.components().Schemas = r.schemas
+	}
+}
+
+// schemaRef returns the schema of typ. The schemas of named struct types are
+// registered as component schemas and referenced. Slices, arrays and maps of
+// named struct types are referencing the component schema of their element
+// type.
+func (r *schemaRegistry) schemaRef(typ reflect.Type) (*jsonschema.Schema, error) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		items, err := r.schemaRef(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s := &jsonschema.Schema{Type: "array", Items: items}
+		if typ.Kind() == reflect.Slice {
+			// nil slices are encoded as null
+			s.Type, s.Types = "", []string{"null", "array"}
+		} else {
+			s.MinItems, s.MaxItems = jsonschema.Ptr(typ.Len()), jsonschema.Ptr(typ.Len())
+		}
+		return s, nil
+	case reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			break
+		}
+		values, err := r.schemaRef(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &jsonschema.Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if typ.Name() == "" {
+			break
+		}
+		name, isDefined := r.names[typ]
+		if isDefined {
+			return &jsonschema.Schema{Ref: schemaRefPrefix + name}, nil
+		}
+		s, err := forType(typ)
+		if err != nil {
+			return nil, fmt.Errorf("schema of %s: %w", typ, err)
+		}
+		name = r.schemaName(typ, "")
+		r.names[typ] = name
+		return r.addSchema(name, s), nil
+	}
+	s, err := forType(typ)
+	if err != nil {
+		return nil, fmt.Errorf("schema of %s: %w", typ, err)
+	}
+	return s, nil
+}
+
+// jsonPatchSchemaRef returns the schema of JSON Patch documents as defined in
+// RFC 6902. It is registered as a component schema on first use.
+func (r *schemaRegistry) jsonPatchSchemaRef() *jsonschema.Schema {
+	typ := reflect.TypeFor[jsonpatch.Patch]()
+	if name, isDefined := r.names[typ]; isDefined {
+		return &jsonschema.Schema{Ref: schemaRefPrefix + name}
+	}
+	pointer := &jsonschema.Schema{Type: "string", Format: "json-pointer"}
+	op := func(required []string, ops ...any) *jsonschema.Schema {
+		return &jsonschema.Schema{
+			Properties: map[string]*jsonschema.Schema{"op": {Enum: ops}},
+			Required:   required,
+		}
+	}
+	s := &jsonschema.Schema{
+		Type: "array",
+		Items: &jsonschema.Schema{
+			Type:     "object",
+			Required: []string{"op", "path"},
+			Properties: map[string]*jsonschema.Schema{
+				"op": {
+					Type: "string",
+					Enum: []any{
+						jsonpatch.OpAdd, jsonpatch.OpRemove, jsonpatch.OpReplace,
+						jsonpatch.OpMove, jsonpatch.OpCopy, jsonpatch.OpTest,
+					},
+				},
+				"path":  pointer,
+				"from":  pointer,
+				"value": {},
+			},
+			OneOf: []*jsonschema.Schema{
+				op([]string{"value"}, jsonpatch.OpAdd, jsonpatch.OpReplace, jsonpatch.OpTest),
+				op(nil, jsonpatch.OpRemove),
+				op([]string{"from"}, jsonpatch.OpMove, jsonpatch.OpCopy),
+			},
+		},
+	}
+	name := "JSONPatch"
+	if r.isSchemaNameTaken(name) {
+		name = r.schemaName(typ, "")
+	}
+	r.names[typ] = name
+	return r.addSchema(name, s)
+}
+
+// patchSchemaRef returns the schema of JSON Merge Patch documents of typ. It
+// is the schema of the patched resource without required properties and with
+// nullable properties as null is deleting a member. The patched resource of
+// generated patch types is the type their ApplyTo method is accepting.
+func (r *schemaRegistry) patchSchemaRef(typ reflect.Type) (*jsonschema.Schema, error) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	resource := patchedResource(typ)
+	if name, isDefined := r.patchNames[resource]; isDefined {
+		return &jsonschema.Schema{Ref: schemaRefPrefix + name}, nil
+	}
+	s, err := forType(resource)
+	if err != nil {
+		return nil, fmt.Errorf("patch schema of %s: %w", resource, err)
+	}
+	patchSchema(s)
+	if resource.Kind() != reflect.Struct || resource.Name() == "" {
+		return s, nil
+	}
+	name := r.schemaName(resource, "Patch")
+	r.patchNames[resource] = name
+	return r.addSchema(name, s), nil
+}
+
+// patchedResource returns the resource patched by the generated patch type
+// typ. If typ is not a generated patch type it is returned as is.
+func patchedResource(typ reflect.Type) reflect.Type {
+	method, hasMethod := reflect.PointerTo(typ).MethodByName("ApplyTo")
+	if !hasMethod || method.Type.NumIn() != 2 || method.Type.NumOut() != 0 {
+		return typ
+	}
+	resource := method.Type.In(1)
+	if resource.Kind() != reflect.Pointer {
+		return typ
+	}
+	return resource.Elem()
+}
+
+// isMergePatch reports whether typ is a generated patch type.
+func isMergePatch(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return patchedResource(typ) != typ
+}
+
+// isJSONPatch reports whether typ is a JSON Patch document.
+func isJSONPatch(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ == reflect.TypeFor[jsonpatch.Patch]()
+}
+
+// patchSchema removes the required properties of s and makes its properties
+// nullable. Objects are patched recursively.
+func patchSchema(s *jsonschema.Schema) {
+	s.Required = nil
+	for _, prop := range s.Properties {
+		patchSchema(prop)
+		nullable(prop)
+	}
+	if s.AdditionalProperties != nil {
+		nullable(s.AdditionalProperties)
+	}
+}
+
+// nullable allows null as value of s.
+func nullable(s *jsonschema.Schema) {
+	switch {
+	case s.Type != "":
+		if s.Type != "null" {
+			s.Type, s.Types = "", []string{s.Type, "null"}
+		}
+	case len(s.Types) > 0:
+		if !slices.Contains(s.Types, "null") {
+			s.Types = append(s.Types, "null")
+		}
+	}
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, nil) {
+		s.Enum = append(s.Enum, nil)
+	}
+}
+
+// Enumer is implemented by types with a fixed set of values which are
+// documented as the enum of their schema. The generated decoders implement
+// Enumer for named types of the package with typed constants.
+type Enumer interface {
+	Enum() []any
+}
+
+// forType returns the schema of typ. Types implementing [Enumer] are
+// restricted to their values.
+func forType(typ reflect.Type) (*jsonschema.Schema, error) {
+	opts := &jsonschema.ForOptions{TypeSchemas: make(map[reflect.Type]*jsonschema.Schema)}
+	enumSchemas(typ, opts.TypeSchemas)
+	s, err := jsonschema.ForType(typ, opts)
+	if err != nil {
+		return nil, err
+	}
+	nullableEnums(s)
+	quotedSchemas(typ, s)
+	return s, nil
+}
+
+// quotedSchemas replaces the schemas of the fields of typ using the `string`
+// option of encoding/json in s as jsonschema-go is ignoring it. The values of
+// these fields are encoded as JSON strings.
+func quotedSchemas(typ reflect.Type, s *jsonschema.Schema) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if s == nil {
+		return
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		quotedSchemas(typ.Elem(), s.Items)
+	case reflect.Map:
+		quotedSchemas(typ.Elem(), s.AdditionalProperties)
+	case reflect.Struct:
+		for i := range typ.NumField() {
+			field := typ.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			if field.Anonymous && name == "" && isStruct(field.Type) {
+				// the fields of embedded structs are promoted
+				quotedSchemas(field.Type, s)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			prop, isDefined := s.Properties[name]
+			if !isDefined {
+				continue
+			}
+			if slices.Contains(strings.Split(opts, ","), "string") && isQuotable(field.Type) {
+				s.Properties[name] = quotedSchema(prop)
+				continue
+			}
+			quotedSchemas(field.Type, prop)
+		}
+	}
+}
+
+func isStruct(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct
+}
+
+// quotedSchema returns a copy of s describing the values of s encoded as JSON
+// strings.
+func quotedSchema(s *jsonschema.Schema) *jsonschema.Schema {
+	quoted := &jsonschema.Schema{
+		Title:       s.Title,
+		Description: s.Description,
+		Type:        "string",
+	}
+	if slices.Contains(s.Types, "null") {
+		quoted.Type, quoted.Types = "", []string{"null", "string"}
+	}
+	for _, v := range s.Enum {
+		if v == nil {
+			quoted.Enum = append(quoted.Enum, nil)
+			continue
+		}
+		data, err := json.Marshal(v)
+		if err == nil {
+			quoted.Enum = append(quoted.Enum, string(data))
+		}
+	}
+	return quoted
+}
+
+// isQuotable reports whether the `string` option of encoding/json applies to
+// values of typ.
+func isQuotable(typ reflect.Type) bool {
+	if typ.Name() == "" && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	default:
+		return false
+	}
+}
+
+// enumSchemas adds the schemas of the types implementing [Enumer] reachable
+// from typ to schemas.
+func enumSchemas(typ reflect.Type, schemas map[reflect.Type]*jsonschema.Schema) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if _, isSeen := schemas[typ]; isSeen {
+		return
+	}
+	if enumer, isEnumer := reflect.Zero(typ).Interface().(Enumer); isEnumer {
+		s, err := jsonschema.ForType(typ, nil)
+		if err == nil {
+			s.Enum = enumer.Enum()
+			schemas[typ] = s
+		}
+		return
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		enumSchemas(typ.Elem(), schemas)
+	case reflect.Struct:
+		// prevent endless recursion of recursive types
+		schemas[typ] = nil
+		defer delete(schemas, typ)
+		for i := range typ.NumField() {
+			enumSchemas(typ.Field(i).Type, schemas)
+		}
+	}
+}
+
+// nullableEnums allows null as value of the enums of nullable schemas.
+func nullableEnums(s *jsonschema.Schema) {
+	if s == nil {
+		return
+	}
+	if slices.Contains(s.Types, "null") {
+		nullable(s)
+	}
+	for _, prop := range s.Properties {
+		nullableEnums(prop)
+	}
+	nullableEnums(s.Items)
+	nullableEnums(s.AdditionalProperties)
+}
+
+// addSchema registers s as the component schema name and returns a reference
+// to it.
+func (r *schemaRegistry) addSchema(name string, s *jsonschema.Schema) *jsonschema.Schema {
+	if r.schemas == nil {
+		r.schemas = make(map[string]*jsonschema.Schema)
+	}
+	r.schemas[name] = s
+	return &jsonschema.Schema{Ref: schemaRefPrefix + name}
+}
+
+// schemaName returns an unused component name for typ with the given suffix.
+// The name of the type is used if possible. Otherwise it is qualified by the
+// import path of the package of the type.
+func (r *schemaRegistry) schemaName(typ reflect.Type, suffix string) string {
+	candidates := []string{
+		sanitizeSchemaName(typ.Name() + suffix),
+		sanitizeSchemaName(strings.ReplaceAll(typ.PkgPath(), "/", ".") + "." + typ.Name() + suffix),
+	}
+	for _, name := range candidates {
+		if !r.isSchemaNameTaken(name) {
+			return name
+		}
+	}
+	// same import path and name e.g. types declared in functions
+	for i := 2; ; i++ {
+		name := candidates[1] + strconv.Itoa(i)
+		if !r.isSchemaNameTaken(name) {
+			return name
+		}
+	}
+}
+
+func (r *schemaRegistry) isSchemaNameTaken(name string) bool {
+	_, isTaken := r.schemas[name]
+	return isTaken
+}
+
+// sanitizeSchemaName turns the name of a type into a valid component name.
+// Instantiated generic types are named by their type arguments without import
+// paths e.g. Page[example.com/api.User] becomes Page-api.User.
+func sanitizeSchemaName(name string) string {
+	name = pkgPathRe.ReplaceAllString(name, "")
+	name = strings.NewReplacer("[", "-", ",", "-", "]", "", "*", "").Replace(name)
+	return invalidSchemaNameRe.ReplaceAllString(name, "_")
+}
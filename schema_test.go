@@ -0,0 +1,322 @@
+package nuage_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/naivary/nuage"
+	"github.com/naivary/nuage/mergepatch"
+	"github.com/naivary/nuage/openapi"
+)
+
+type page[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next,omitempty"`
+}
+
+func TestSchemas(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	create := func(ctx *nuage.Context, r *createUserRequest) (*user, error) { return &r.User, nil }
+	list := func(ctx *nuage.Context, r *emptyRequest) (page[user], error) { return page[user]{}, nil }
+	if err := nuage.Handle(n, create, &openapi.Operation{Pattern: "POST /{tenant}/users"}); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	if err := nuage.Handle(n, list, &openapi.Operation{Pattern: "GET /users"}); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+
+	doc := n.Document()
+	op := doc.Paths["/{tenant}/users"].Post
+	if ref := op.RequestBody.Content[nuage.ContentTypeJSON].Schema.Ref; ref != "#/components/schemas/user" {
+		t.Errorf("request body ref: got %q", ref)
+	}
+	if ref := op.Responses["200"].Content[nuage.ContentTypeJSON].Schema.Ref; ref != "#/components/schemas/user" {
+		t.Errorf("response ref: got %q", ref)
+	}
+	if ref := doc.Paths["/users"].Get.Responses["200"].Content[nuage.ContentTypeJSON].Schema.Ref; ref != "#/components/schemas/page-nuage_test.user" {
+		t.Errorf("generic response ref: got %q", ref)
+	}
+	schema := doc.Components.Schemas["user"]
+	if schema == nil || schema.Properties["name"] == nil {
+		t.Fatalf("user schema not registered: %v", schema)
+	}
+	if len(doc.Components.Schemas) != 2 {
+		t.Errorf("expected 2 schemas: got %d", len(doc.Components.Schemas))
+	}
+}
+
+type orphan struct {
+	Name string `json:"name"`
+}
+
+func TestSchemas_FailedHandle(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if err := nuage.Handle(n, noop, &openapi.Operation{Pattern: "GET /orphans"}); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	get := func(ctx *nuage.Context, r *emptyRequest) (orphan, error) { return orphan{}, nil }
+	if err := nuage.Handle(n, get, &openapi.Operation{Pattern: "GET /orphans"}); err == nil {
+		t.Fatalf("expected an error for the duplicate pattern")
+	}
+	if components := n.Document().Components; components != nil && components.Schemas["orphan"] != nil {
+		t.Errorf("schema of the failed operation is registered")
+	}
+	// the schema is registered once the operation is registered
+	if err := nuage.Handle(n, get, &openapi.Operation{Pattern: "GET /orphans/{id}"}); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	if n.Document().Components.Schemas["orphan"] == nil {
+		t.Errorf("schema is not registered")
+	}
+}
+
+type userPatch struct {
+	Name mergepatch.Field[string] `json:"name,omitzero"`
+}
+
+func (p *userPatch) ApplyTo(u *user) {
+	p.Name.Apply(&u.Name)
+}
+
+type patchUserRequest struct {
+	Patch userPatch `body:""`
+}
+
+func (r *patchUserRequest) Decode(req *http.Request) error {
+	return json.NewDecoder(req.Body).Decode(&r.Patch)
+}
+
+func TestSchemas_MergePatch(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	patch := func(ctx *nuage.Context, r *patchUserRequest) (user, error) {
+		var u user
+		r.Patch.ApplyTo(&u)
+		return u, nil
+	}
+	op := &openapi.Operation{Pattern: "PATCH /users/{id}"}
+	if err := nuage.Handle(n, patch, op); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	if op.RequestContentType != nuage.ContentTypeMergePatch {
+		t.Errorf("request content type: got %s", op.RequestContentType)
+	}
+	if ref := op.RequestBody.Content[nuage.ContentTypeMergePatch].Schema.Ref; ref != "#/components/schemas/userPatch" {
+		t.Errorf("request body ref: got %q", ref)
+	}
+	schema := n.Document().Components.Schemas["userPatch"]
+	if schema == nil {
+		t.Fatalf("patch schema not registered")
+	}
+	if len(schema.Required) != 0 {
+		t.Errorf("patch schema requires properties: %v", schema.Required)
+	}
+	if types := schema.Properties["name"].Types; !slices.Equal(types, []string{"string", "null"}) {
+		t.Errorf("name is not nullable: %v", types)
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(`{"name":"jane"}`))
+	req.Header.Set("Content-Type", nuage.ContentTypeMergePatch)
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "jane") {
+		t.Errorf("patch: got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+type wallet struct {
+	Balance float64 `json:"balance,string"`
+	Rank    *int    `json:"rank,string"`
+}
+
+type walletPatch struct {
+	Balance mergepatch.QuotedField[float64] `json:"balance,omitzero"`
+	Rank    mergepatch.QuotedField[*int]    `json:"rank,omitzero"`
+}
+
+func (p *walletPatch) ApplyTo(w *wallet) {
+	p.Balance.Apply(&w.Balance)
+	p.Rank.Apply(&w.Rank)
+}
+
+type patchWalletRequest struct {
+	Patch walletPatch `body:""`
+}
+
+func (r *patchWalletRequest) Decode(req *http.Request) error {
+	return json.NewDecoder(req.Body).Decode(&r.Patch)
+}
+
+func TestSchemas_QuotedMergePatch(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	patch := func(ctx *nuage.Context, r *patchWalletRequest) (wallet, error) {
+		var w wallet
+		r.Patch.ApplyTo(&w)
+		return w, nil
+	}
+	if err := nuage.Handle(n, patch, &openapi.Operation{Pattern: "PATCH /wallets/{id}"}); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	schema := n.Document().Components.Schemas["walletPatch"]
+	if schema == nil {
+		t.Fatalf("patch schema not registered")
+	}
+	for _, name := range []string{"balance", "rank"} {
+		if types := schema.Properties[name].Types; !slices.Contains(types, "string") || !slices.Contains(types, "null") {
+			t.Errorf("%s is not a nullable string: %v", name, types)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/wallets/1", strings.NewReader(`{"balance":"2.5","rank":"1"}`))
+	req.Header.Set("Content-Type", nuage.ContentTypeMergePatch)
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, req)
+	if want := `{"balance":"2.5","rank":"1"}`; rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != want {
+		t.Errorf("patch: got %d %s want %s", rec.Code, rec.Body.String(), want)
+	}
+}
+
+type listUsersRequest struct {
+	Tenant string   `path:"tenant,pattern=^[a-z]+$"`
+	Limit  int      `query:"limit,min=1,max=100,default=20"`
+	Order  string   `query:"order,enum=asc|desc"`
+	Tags   []string `query:"tags,maxItems=5,maxLength=16,default=a|b"`
+}
+
+func (r *listUsersRequest) Decode(req *http.Request) error { return nil }
+
+func TestSchemas_Parameters(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	list := func(ctx *nuage.Context, r *listUsersRequest) ([]user, error) { return nil, nil }
+	op := &openapi.Operation{Pattern: "GET /{tenant}/users"}
+	if err := nuage.Handle(n, list, op); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	params := make(map[string]*openapi.Parameter, len(op.Parameters))
+	for _, param := range op.Parameters {
+		params[param.Name] = param
+	}
+	if def := string(params["limit"].Schema.Default); def != "20" {
+		t.Errorf("limit default: got %s", def)
+	}
+	if def := string(params["tags"].Schema.Default); def != `["a","b"]` {
+		t.Errorf("tags default: got %s", def)
+	}
+	if len(params) != 4 {
+		t.Fatalf("expected 4 parameters: got %d", len(params))
+	}
+	if s := params["tenant"].Schema; s.Type != "string" || s.Pattern != "^[a-z]+$" || !params["tenant"].Required {
+		t.Errorf("tenant: %+v", s)
+	}
+	if s := params["limit"].Schema; s.Type != "integer" || *s.Minimum != 1 || *s.Maximum != 100 {
+		t.Errorf("limit: %+v", s)
+	}
+	if s := params["order"].Schema; !slices.Equal(s.Enum, []any{"asc", "desc"}) {
+		t.Errorf("order: %+v", s)
+	}
+	if s := params["tags"].Schema; s.Type != "array" || *s.MaxItems != 5 || *s.Items.MaxLength != 16 {
+		t.Errorf("tags: %+v", s)
+	}
+}
+
+type invalidParamRequest struct {
+	Name string `query:"name,min=1"`
+}
+
+type invalidDefaultRequest struct {
+	Limit int `query:"limit,default=ten"`
+}
+
+func (r *invalidDefaultRequest) Decode(req *http.Request) error { return nil }
+
+type violatingDefaultRequest struct {
+	Limit int `query:"limit,default=0,min=1"`
+}
+
+func (r *violatingDefaultRequest) Decode(req *http.Request) error { return nil }
+
+func (r *invalidParamRequest) Decode(req *http.Request) error { return nil }
+
+func TestSchemas_InvalidParameter(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	hl := func(ctx *nuage.Context, r *invalidParamRequest) ([]user, error) { return nil, nil }
+	if err := nuage.Handle(n, hl, &openapi.Operation{Pattern: "GET /users"}); err == nil {
+		t.Errorf("expected error for min on a string parameter")
+	}
+	invalidDefault := func(ctx *nuage.Context, r *invalidDefaultRequest) ([]user, error) { return nil, nil }
+	if err := nuage.Handle(n, invalidDefault, &openapi.Operation{Pattern: "GET /accounts"}); err == nil {
+		t.Errorf("expected error for a default which is not an integer")
+	}
+	violatingDefault := func(ctx *nuage.Context, r *violatingDefaultRequest) ([]user, error) { return nil, nil }
+	if err := nuage.Handle(n, violatingDefault, &openapi.Operation{Pattern: "GET /wallets"}); err == nil {
+		t.Errorf("expected error for a default violating the minimum")
+	}
+}
+
+type status string
+
+func (status) Enum() []any { return []any{status("active"), status("inactive")} }
+
+type account struct {
+	Status *status `json:"status"`
+}
+
+type createAccountRequest struct {
+	Status  status  `query:"status"`
+	Account account `body:""`
+}
+
+func (r *createAccountRequest) Decode(req *http.Request) error {
+	return json.NewDecoder(req.Body).Decode(&r.Account)
+}
+
+func TestSchemas_Enum(t *testing.T) {
+	n, err := nuage.New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	create := func(ctx *nuage.Context, r *createAccountRequest) (account, error) { return r.Account, nil }
+	op := &openapi.Operation{Pattern: "POST /accounts"}
+	if err := nuage.Handle(n, create, op); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	want := []any{status("active"), status("inactive")}
+	if enum := op.Parameters[0].Schema.Enum; !slices.Equal(enum, want) {
+		t.Errorf("parameter enum: got %v", enum)
+	}
+	prop := n.Document().Components.Schemas["account"].Properties["status"]
+	if !slices.Equal(prop.Enum, append(want, nil)) {
+		t.Errorf("property enum: got %v", prop.Enum)
+	}
+
+	for body, code := range map[string]int{`{"status":"active"}`: http.StatusOK, `{"status":null}`: http.StatusOK, `{"status":"gone"}`: http.StatusUnprocessableEntity} {
+		req := httptest.NewRequest(http.MethodPost, "/accounts", strings.NewReader(body))
+		req.Header.Set("Content-Type", nuage.ContentTypeJSON)
+		rec := httptest.NewRecorder()
+		n.ServeHTTP(rec, req)
+		if rec.Code != code {
+			t.Errorf("%s: got %d %s", body, rec.Code, rec.Body.String())
+		}
+	}
+}
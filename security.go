@@ -0,0 +1,203 @@
+package nuage
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/naivary/nuage/openapi"
+)
+
+// Authenticator authenticates the credentials of a request for a security
+// scheme registered using [Nuage.RegisterSecurityScheme].
+type Authenticator interface {
+	// Authenticate returns the principal identified by the credentials of r.
+	// If r is carrying no or invalid credentials an [HTTPError] with the
+	// status 401 (Unauthorized) should be returned. WWW-Authenticate
+	// challenges in the header of the error are sent to the client.
+	Authenticate(ctx *Context, r *http.Request) (*Principal, error)
+}
+
+// AuthenticatorFunc is an adapter to allow the use of ordinary functions as
+// an [Authenticator].
+type AuthenticatorFunc func(ctx *Context, r *http.Request) (*Principal, error)
+
+func (fn AuthenticatorFunc) Authenticate(ctx *Context, r *http.Request) (*Principal, error) {
+	return fn(ctx, r)
+}
+
+// RegisterSecurityScheme adds scheme under name to the components of the
+// OpenAPI document and uses authn to authenticate requests for operations
+// requiring the scheme in their security requirements. Security schemes have
+// to be registered before the operations referencing them.
+func (n *Nuage) RegisterSecurityScheme(name string, scheme *openapi.SecurityScheme, authn Authenticator) error {
+	if name == "" {
+		return errors.New("security scheme: name is empty")
+	}
+	if scheme == nil || authn == nil {
+		return fmt.Errorf("security scheme %q: scheme and authenticator are required", name)
+	}
+	components := n.components()
+	if _, isDefined := components.SecuritySchemes[name]; isDefined {
+		return fmt.Errorf("security scheme %q is already registered", name)
+	}
+	if components.SecuritySchemes == nil {
+		components.SecuritySchemes = make(map[string]*openapi.SecurityScheme)
+	}
+	components.SecuritySchemes[name] = scheme
+	n.authenticators[name] = authn
+	return nil
+}
+
+func (n *Nuage) components() *openapi.Components {
+	if n.doc.Components == nil {
+		n.doc.Components = &openapi.Components{}
+	}
+	return n.doc.Components
+}
+
+// validateSecurity reports whether all security schemes referenced by the
+// security requirements of op are registered.
+func (n *Nuage) validateSecurity(op *openapi.Operation) error {
+	for _, req := range op.Security {
+		for name := range req {
+			if _, isDefined := n.authenticators[name]; !isDefined {
+				return fmt.Errorf("security scheme %q is not registered", name)
+			}
+		}
+	}
+	return nil
+}
+
+// security returns a middleware enforcing the security requirements of the
+// operation. The requirements are alternatives of which at least one has to
+// be satisfied. A requirement is satisfied if the request is authenticated by
+// all of its schemes and the principals are granted all listed scopes.
+//
+// Requests failing authentication are answered with 401 (Unauthorized) and the
+// challenges of all schemes. Authenticated requests lacking scopes are
+// answered with 403 (Forbidden).
+func (n *Nuage) security(next NextFunc) NextFunc {
+	return func(ctx *Context, w http.ResponseWriter, r *http.Request) error {
+		reqs := ctx.Operation().Security
+		if len(reqs) == 0 {
+			return next(ctx, w, r)
+		}
+		a := authorization{
+			n:          n,
+			principals: make(map[string]*Principal, 1),
+			errs:       make(map[string]error, 1),
+		}
+		for _, req := range reqs {
+			p, isSatisfied := a.satisfy(ctx, r, req)
+			if isSatisfied {
+				if p != nil {
+					ctx.SetPrincipal(p)
+				}
+				return next(ctx, w, r)
+			}
+		}
+		return a.err()
+	}
+}
+
+// authorization is the evaluation of the security requirements of a single
+// request. Every scheme is authenticated at most once.
+type authorization struct {
+	n *Nuage
+
+	principals map[string]*Principal
+	errs       map[string]error
+
+	// missingScopes of the requirements which were authenticated but not
+	// authorized.
+	missingScopes []string
+}
+
+// satisfy reports whether req is satisfied and returns the principal of the
+// first scheme in lexical order of req.
+func (a *authorization) satisfy(ctx *Context, r *http.Request, req openapi.SecurityRequirement) (*Principal, bool) {
+	names := slices.Sorted(maps.Keys(req))
+	principals := make([]*Principal, 0, len(names))
+	for _, name := range names {
+		p, err := a.authenticate(ctx, r, name)
+		if err != nil {
+			return nil, false
+		}
+		principals = append(principals, p)
+	}
+	var missing []string
+	for i, name := range names {
+		for _, scope := range req[name] {
+			if !slices.Contains(principals[i].Scopes, scope) {
+				missing = append(missing, scope)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		a.missingScopes = append(a.missingScopes, missing...)
+		return nil, false
+	}
+	if len(principals) == 0 {
+		// empty requirement allowing anonymous access
+		return nil, true
+	}
+	return principals[0], true
+}
+
+func (a *authorization) authenticate(ctx *Context, r *http.Request, name string) (*Principal, error) {
+	if p, isAuthenticated := a.principals[name]; isAuthenticated {
+		return p, nil
+	}
+	if err, hasFailed := a.errs[name]; hasFailed {
+		return nil, err
+	}
+	p, err := a.n.authenticators[name].Authenticate(ctx, r)
+	if err == nil && p == nil {
+		err = ErrUnauthorized
+	}
+	if err != nil {
+		a.errs[name] = err
+		return nil, err
+	}
+	// the principal might be shared by the authenticator
+	principal := *p
+	principal.Scheme = name
+	a.principals[name] = &principal
+	return &principal, nil
+}
+
+// err returns the error of a request not satisfying any requirement.
+func (a *authorization) err() error {
+	if len(a.missingScopes) > 0 {
+		slices.Sort(a.missingScopes)
+		scopes := strings.Join(slices.Compact(a.missingScopes), " ")
+		return ErrForbidden.
+			WithHeader("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scopes)).
+			WithDetail("insufficient scope: " + scopes)
+	}
+	httpErr := ErrUnauthorized.WithDetail("")
+	httpErr.Header = make(http.Header, 1)
+	for _, name := range slices.Sorted(maps.Keys(a.errs)) {
+		err := a.errs[name]
+		if e := asHTTPError(err); e != nil {
+			if e.Status != http.StatusUnauthorized {
+				// failures other than missing authentication e.g. an
+				// unavailable identity provider are reported as is
+				return err
+			}
+			if httpErr.Detail == "" {
+				httpErr.Detail = e.Detail
+			}
+			for _, challenge := range e.Header.Values("WWW-Authenticate") {
+				httpErr.Header.Add("WWW-Authenticate", challenge)
+			}
+			continue
+		}
+		return err
+	}
+	return httpErr
+}
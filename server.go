@@ -0,0 +1,107 @@
+package nuage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/signal"
+	"slices"
+	"syscall"
+)
+
+// ShutdownHook is called when the server is shutting down after all
+// in-flight requests have been drained.
+type ShutdownHook func(ctx context.Context) error
+
+// OnShutdown registers hook to be called when the server is shutting down.
+// Hooks are called in reverse order of their registration.
+func (n *Nuage) OnShutdown(hook ShutdownHook) {
+	n.shutdownHooks = append(n.shutdownHooks, hook)
+}
+
+// ListenAndServe listens on the configured address and serves the registered
+// operations until ctx is canceled or the process receives SIGINT or SIGTERM.
+// In-flight requests are given the configured grace period to complete before
+// the shutdown hooks are called.
+func (n *Nuage) ListenAndServe(ctx context.Context) error {
+	return n.serve(ctx, func(srv *http.Server, ln net.Listener) error {
+		return srv.Serve(ln)
+	})
+}
+
+// ListenAndServeTLS is like [Nuage.ListenAndServe] but serves HTTPS using the
+// configured certificate. If client authorities are configured client
+// certificates are verified against them. The files are reloaded in the
+// configured interval if they change.
+func (n *Nuage) ListenAndServeTLS(ctx context.Context) error {
+	if n.cfg.TLSCertFile == "" {
+		return errors.New("tls: certificate and key file are not configured")
+	}
+	reloader, err := newCertReloader(n.cfg)
+	if err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go reloader.watch(ctx, n.cfg.TLSReloadInterval, n.logger)
+	return n.serve(ctx, func(srv *http.Server, ln net.Listener) error {
+		srv.TLSConfig = reloader.tlsConfig()
+		return srv.ServeTLS(ln, "", "")
+	})
+}
+
+func (n *Nuage) serve(ctx context.Context, serve func(srv *http.Server, ln net.Listener) error) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ln, err := net.Listen("tcp", n.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Addr:              n.cfg.Addr,
+		Handler:           n,
+		ReadTimeout:       n.cfg.ReadTimeout,
+		ReadHeaderTimeout: n.cfg.ReadHeaderTimeout,
+		WriteTimeout:      n.cfg.WriteTimeout,
+		IdleTimeout:       n.cfg.IdleTimeout,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- serve(srv, ln)
+	}()
+	n.logger.Info("server is listening", "addr", ln.Addr().String())
+	select {
+	case err = <-errCh:
+		// the server failed before a shutdown was requested
+	case <-ctx.Done():
+		n.logger.Info("server is shutting down", "grace_period", n.cfg.ShutdownGracePeriod)
+		err = n.shutdown(context.WithoutCancel(ctx), srv)
+		<-errCh
+	}
+	return errors.Join(err, n.runShutdownHooks(context.WithoutCancel(ctx)))
+}
+
+// shutdown stops srv from accepting new connections and drains the in-flight
+// requests within the grace period. Remaining connections are closed
+// forcefully after the grace period.
+func (n *Nuage) shutdown(ctx context.Context, srv *http.Server) error {
+	ctx, cancel := context.WithTimeout(ctx, n.cfg.ShutdownGracePeriod)
+	defer cancel()
+	err := srv.Shutdown(ctx)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, srv.Close())
+}
+
+func (n *Nuage) runShutdownHooks(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, n.cfg.ShutdownGracePeriod)
+	defer cancel()
+	var errs []error
+	for _, hook := range slices.Backward(n.shutdownHooks) {
+		errs = append(errs, hook(ctx))
+	}
+	return errors.Join(errs...)
+}
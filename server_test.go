@@ -0,0 +1,159 @@
+package nuage_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/naivary/nuage"
+	"github.com/naivary/nuage/openapi"
+)
+
+// freeAddr returns a local address which is not in use.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// waitListening blocks until the server is accepting connections on addr.
+func waitListening(t *testing.T, addr string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("server is not listening on %s", addr)
+}
+
+// slowRequest is served by a handler blocking until release is closed.
+type slowRequest struct{}
+
+func (r *slowRequest) Decode(req *http.Request) error { return nil }
+
+func newSlowServer(t *testing.T, gracePeriod time.Duration) (n *nuage.Nuage, started chan struct{}, release chan struct{}) {
+	t.Helper()
+	cfg := nuage.DefaultConfig()
+	cfg.Addr = freeAddr(t)
+	cfg.ShutdownGracePeriod = gracePeriod
+	n, err := nuage.NewWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	started = make(chan struct{})
+	release = make(chan struct{})
+	hl := func(ctx *nuage.Context, r *slowRequest) (nuage.NoContent, error) {
+		close(started)
+		<-release
+		return nuage.NoContent{}, nil
+	}
+	if err := nuage.Handle(n, hl, &openapi.Operation{Pattern: "GET /slow"}); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	return n, started, release
+}
+
+func TestListenAndServe_Shutdown(t *testing.T) {
+	n, started, release := newSlowServer(t, 5*time.Second)
+	addr := n.Config().Addr
+	var hooks []string
+	errFirst := errors.New("first hook failed")
+	errLast := errors.New("last hook failed")
+	hook := func(name string, err error) nuage.ShutdownHook {
+		return func(ctx context.Context) error {
+			hooks = append(hooks, name)
+			return err
+		}
+	}
+	n.OnShutdown(hook("first", errFirst))
+	n.OnShutdown(hook("second", nil))
+	n.OnShutdown(hook("last", errLast))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	served := make(chan error, 1)
+	go func() {
+		served <- n.ListenAndServe(ctx)
+	}()
+	waitListening(t, addr)
+
+	status := make(chan int, 1)
+	go func() {
+		res, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			t.Errorf("in-flight request: %v", err)
+			status <- 0
+			return
+		}
+		res.Body.Close()
+		status <- res.StatusCode
+	}()
+	<-started
+	cancel()
+	// the in-flight request is drained before the server returns
+	select {
+	case err := <-served:
+		t.Fatalf("server returned before draining the in-flight request: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, err := http.Get("http://" + addr + "/slow"); err == nil {
+		t.Errorf("new connections are accepted while shutting down")
+	}
+	close(release)
+	if got := <-status; got != http.StatusNoContent {
+		t.Errorf("in-flight request: got status %d want %d", got, http.StatusNoContent)
+	}
+	err := <-served
+	if !errors.Is(err, errFirst) || !errors.Is(err, errLast) {
+		t.Errorf("errors of the hooks are not joined: %v", err)
+	}
+	if got, want := hooks, []string{"last", "second", "first"}; !slices.Equal(got, want) {
+		t.Errorf("hooks: got %v want %v", got, want)
+	}
+}
+
+func TestListenAndServe_GracePeriod(t *testing.T) {
+	n, started, release := newSlowServer(t, 50*time.Millisecond)
+	defer close(release)
+	addr := n.Config().Addr
+	isHookCalled := false
+	n.OnShutdown(func(ctx context.Context) error {
+		isHookCalled = true
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	served := make(chan error, 1)
+	go func() {
+		served <- n.ListenAndServe(ctx)
+	}()
+	waitListening(t, addr)
+	go http.Get("http://" + addr + "/slow")
+	<-started
+	cancel()
+	select {
+	case err := <-served:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("exceeding the grace period: got %v want %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("server did not shut down within the grace period")
+	}
+	if !isHookCalled {
+		t.Errorf("hooks are not called after exceeding the grace period")
+	}
+}
@@ -0,0 +1,175 @@
+package nuage
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/naivary/nuage/openapi"
+)
+
+// certReloader provides the server certificate and the pool of client
+// authorities loaded from disk. The files are reloaded when they change
+// allowing to rotate certificates without a restart.
+type certReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	pool     *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+func newCertReloader(cfg *Config) (*certReloader, error) {
+	r := &certReloader{
+		certFile:     cfg.TLSCertFile,
+		keyFile:      cfg.TLSKeyFile,
+		clientCAFile: cfg.TLSClientCAFile,
+	}
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload loads the files if any of them has been modified since the last
+// load. It reports whether the files have been reloaded.
+func (r *certReloader) reload() (bool, error) {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+	modTimes := make(map[string]time.Time, len(files))
+	isModified := false
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, err
+		}
+		modTimes[file] = info.ModTime()
+		if !info.ModTime().Equal(r.modTimes[file]) {
+			isModified = true
+		}
+	}
+	if !isModified {
+		return false, nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, err
+	}
+	var pool *x509.CertPool
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return false, err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return false, fmt.Errorf("no certificates found in %s", r.clientCAFile)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.pool = pool
+	r.modTimes = modTimes
+	return true, nil
+}
+
+// watch reloads the files in the given interval until ctx is canceled.
+func (r *certReloader) watch(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		isReloaded, err := r.reload()
+		if err != nil {
+			// the previously loaded certificates are kept in use
+			logger.Error("certificates could not be reloaded", "err", err)
+			continue
+		}
+		if isReloaded {
+			logger.Info("certificates reloaded")
+		}
+	}
+}
+
+// tlsConfig returns the TLS configuration of the server. Client certificates
+// are requested and verified if client authorities are configured. Whether a
+// certificate is required is decided per operation by its security
+// requirements.
+func (r *certReloader) tlsConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.cert, nil
+		},
+	}
+	if r.clientCAFile == "" {
+		return base
+	}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		cfg.ClientCAs = r.pool
+		return cfg, nil
+	}
+	return base
+}
+
+var _ Authenticator = MutualTLSAuthenticator{}
+
+// MutualTLSAuthenticator authenticates requests using the client certificate
+// verified during the TLS handshake.
+type MutualTLSAuthenticator struct{}
+
+// Authenticate returns the principal identified by the verified client
+// certificate of r. The subject of the principal is the distinguished name of
+// the certificate. The subject alternative names are accessible through
+// [Principal.Certificate].
+func (MutualTLSAuthenticator) Authenticate(ctx *Context, r *http.Request) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrUnauthorized.WithDetail("verified client certificate is missing")
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	return &Principal{
+		Subject:     cert.Subject.String(),
+		Certificate: cert,
+	}, nil
+}
+
+// RegisterMutualTLS registers a mutualTLS security scheme under name which is
+// authenticating requests using their client certificate. Client authorities
+// have to be configured.
+func (n *Nuage) RegisterMutualTLS(name string) error {
+	if n.cfg.TLSClientCAFile == "" {
+		return errors.New("mutual tls: client authorities are not configured")
+	}
+	scheme := &openapi.SecurityScheme{
+		Type: openapi.SecurityTypeMutualTLS,
+	}
+	return n.RegisterSecurityScheme(name, scheme, MutualTLSAuthenticator{})
+}
@@ -0,0 +1,271 @@
+package nuage_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/naivary/nuage"
+	"github.com/naivary/nuage/openapi"
+)
+
+// certificate is a PEM encoded certificate and its private key.
+type certificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newCertificate returns a certificate for name signed by parent. The
+// certificate is self-signed if parent is nil.
+func newCertificate(t *testing.T, name string, parent *certificate, isCA bool) *certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatalf("serial: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name, Organization: []string{"nuage"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		DNSNames:              []string{name},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	if isCA {
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return &certificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c *certificate) tlsCertificate(t *testing.T) *tls.Certificate {
+	t.Helper()
+	cert, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	if err != nil {
+		t.Fatalf("key pair: %v", err)
+	}
+	return &cert
+}
+
+// writeFile writes data to file and advances its modification time to make
+// the change visible to the reloading of the certificates.
+func writeFile(t *testing.T, file string, data []byte, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+// newTLSClient returns a client trusting ca. If cert is not nil it is
+// presented regardless of the authorities accepted by the server.
+func newTLSClient(ca *certificate, cert *tls.Certificate) *http.Client {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs: roots,
+				GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					if cert == nil {
+						return &tls.Certificate{}, nil
+					}
+					return cert, nil
+				},
+			},
+			// every request has to perform a new handshake
+			DisableKeepAlives: true,
+		},
+	}
+}
+
+func TestListenAndServeTLS(t *testing.T) {
+	ca := newCertificate(t, "ca", nil, true)
+	server := newCertificate(t, "server-1", ca, false)
+	dir := t.TempDir()
+	now := time.Now()
+	cfg := nuage.DefaultConfig()
+	cfg.Addr = freeAddr(t)
+	cfg.TLSCertFile = filepath.Join(dir, "tls.crt")
+	cfg.TLSKeyFile = filepath.Join(dir, "tls.key")
+	cfg.TLSClientCAFile = filepath.Join(dir, "ca.crt")
+	cfg.TLSReloadInterval = 10 * time.Millisecond
+	writeFile(t, cfg.TLSCertFile, server.certPEM, now)
+	writeFile(t, cfg.TLSKeyFile, server.keyPEM, now)
+	writeFile(t, cfg.TLSClientCAFile, ca.certPEM, now)
+
+	n, err := nuage.NewWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if err := n.RegisterMutualTLS("mtls"); err != nil {
+		t.Fatalf("register mutual tls: %v", err)
+	}
+	var principal *nuage.Principal
+	hl := func(ctx *nuage.Context, r *emptyRequest) (nuage.NoContent, error) {
+		principal = ctx.Principal()
+		return nuage.NoContent{}, nil
+	}
+	op := &openapi.Operation{
+		Pattern:  "GET /internal",
+		Security: []openapi.SecurityRequirement{{"mtls": {}}},
+	}
+	if err := nuage.Handle(n, hl, op); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	if err := nuage.Handle(n, hl, &openapi.Operation{Pattern: "GET /public"}); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- n.ListenAndServeTLS(ctx)
+	}()
+	defer func() {
+		cancel()
+		if err := <-served; err != nil {
+			t.Errorf("listen and serve tls: %v", err)
+		}
+	}()
+	waitListening(t, cfg.Addr)
+	url := "https://" + cfg.Addr
+
+	t.Run("client certificates", func(t *testing.T) {
+		client := newCertificate(t, "client", ca, false)
+		selfSigned := newCertificate(t, "client", nil, false)
+		tests := []struct {
+			name    string
+			path    string
+			client  *http.Client
+			status  int
+			subject string
+		}{
+			{
+				name:    "signed by client authority",
+				path:    "/internal",
+				client:  newTLSClient(ca, client.tlsCertificate(t)),
+				status:  http.StatusNoContent,
+				subject: client.cert.Subject.String(),
+			},
+			{
+				name:   "missing",
+				path:   "/internal",
+				client: newTLSClient(ca, nil),
+				status: http.StatusUnauthorized,
+			},
+			{
+				name:   "not required",
+				path:   "/public",
+				client: newTLSClient(ca, nil),
+				status: http.StatusNoContent,
+			},
+			{
+				// certificates which can not be verified are rejected
+				// during the handshake
+				name:   "self-signed",
+				path:   "/internal",
+				client: newTLSClient(ca, selfSigned.tlsCertificate(t)),
+			},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				principal = nil
+				res, err := tc.client.Get(url + tc.path)
+				if tc.status == 0 {
+					if err == nil {
+						res.Body.Close()
+						t.Fatalf("expected the handshake to fail: got status %d", res.StatusCode)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("get: %v", err)
+				}
+				res.Body.Close()
+				if res.StatusCode != tc.status {
+					t.Fatalf("status: got %d want %d", res.StatusCode, tc.status)
+				}
+				if tc.subject == "" {
+					return
+				}
+				if principal == nil || principal.Subject != tc.subject {
+					t.Fatalf("principal: got %+v want subject %s", principal, tc.subject)
+				}
+				if principal.Certificate == nil || principal.Certificate.Subject.CommonName != "client" {
+					t.Errorf("certificate of the principal is missing")
+				}
+			})
+		}
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		client := newTLSClient(ca, nil)
+		leaf := func() string {
+			res, err := client.Get(url + "/public")
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			res.Body.Close()
+			return res.TLS.PeerCertificates[0].Subject.CommonName
+		}
+		if got := leaf(); got != "server-1" {
+			t.Fatalf("leaf: got %s want server-1", got)
+		}
+		rotated := newCertificate(t, "server-2", ca, false)
+		later := now.Add(time.Minute)
+		writeFile(t, cfg.TLSKeyFile, rotated.keyPEM, later)
+		writeFile(t, cfg.TLSCertFile, rotated.certPEM, later)
+		deadline := time.Now().Add(5 * time.Second)
+		for leaf() != "server-2" {
+			if time.Now().After(deadline) {
+				t.Fatalf("rotated certificate is not served")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
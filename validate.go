@@ -0,0 +1,256 @@
+package nuage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// Violation is a violation of the schema of a request body.
+type Violation struct {
+	// Pointer is the JSON Pointer (RFC 6901) of the invalid value
+	Pointer string `json:"pointer"`
+
+	// Keyword of the schema which is violated
+	Keyword string `json:"keyword"`
+
+	Message string `json:"message"`
+}
+
+// validator validates request bodies against the schema of an operation
+// using jsonschema-go. The violations are located by validating the
+// properties and items of invalid values against their own schemas.
+type validator struct {
+	schema *jsonschema.Schema
+
+	// resolved are the resolved schemas of schema and of its properties and
+	// items
+	resolved map[*jsonschema.Schema]*jsonschema.Resolved
+
+	// patterns are the compiled regular expressions of the pattern
+	// properties
+	patterns map[string]*regexp.Regexp
+}
+
+// newValidator returns a validator of schema. The references to the component
+// schemas are inlined. Unknown object members are allowed unless the request
+// body is strict even though the inferred schemas of structs are forbidding
+// them.
+func newValidator(schema *jsonschema.Schema, components map[string]*jsonschema.Schema, strict bool) (*validator, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	s := schema.CloneSchemas()
+	if err := inlineRefs(s, components, strict, nil); err != nil {
+		return nil, err
+	}
+	v := &validator{
+		schema:   s,
+		resolved: make(map[*jsonschema.Schema]*jsonschema.Resolved),
+		patterns: make(map[string]*regexp.Regexp),
+	}
+	if err := v.resolve(s); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// resolve resolves s and the schemas of its properties and items.
+func (v *validator) resolve(s *jsonschema.Schema) error {
+	resolved, err := s.Resolve(nil)
+	if err != nil {
+		return fmt.Errorf("schema cannot be resolved: %w", err)
+	}
+	v.resolved[s] = resolved
+	for expr := range s.PatternProperties {
+		// the expression is checked by Resolve
+		v.patterns[expr] = regexp.MustCompile(expr)
+	}
+	children := slices.Concat(
+		slices.Collect(maps.Values(s.Properties)),
+		slices.Collect(maps.Values(s.PatternProperties)),
+		s.PrefixItems,
+		[]*jsonschema.Schema{s.Items, s.AdditionalProperties},
+	)
+	for _, child := range children {
+		if child == nil || isFalseSchema(child) {
+			continue
+		}
+		if err := v.resolve(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate validates the JSON document data. All violations of the schema
+// are returned as an [ErrRequestBodyValidation]. Syntax errors are left to
+// the decoder of the request model.
+func (v *validator) validate(data []byte) error {
+	if v == nil {
+		return nil
+	}
+	// numbers are decoded as float64 which is cheap to compare compared to
+	// exact arithmetic on arbitrary large numbers
+	var instance any
+	if err := json.Unmarshal(data, &instance); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return ErrRequestBodyInvalid.WithDetail(err.Error())
+		}
+		return nil
+	}
+	violations := v.locate(v.schema, instance, "", nil)
+	if len(violations) == 0 {
+		return nil
+	}
+	return ErrRequestBodyValidation.
+		WithDetail(fmt.Sprintf("request body violates the schema in %d places", len(violations))).
+		WithExtension("errors", violations)
+}
+
+// locate validates instance at the location ptr against s. If instance is
+// invalid the violations of its properties and items are appended to
+// violations. Otherwise instance itself is violating s.
+func (v *validator) locate(s *jsonschema.Schema, instance any, ptr string, violations []Violation) []Violation {
+	err := v.resolved[s].Validate(instance)
+	if err == nil {
+		return violations
+	}
+	n := len(violations)
+	switch value := instance.(type) {
+	case map[string]any:
+		for _, name := range slices.Sorted(maps.Keys(value)) {
+			for _, child := range v.propertySchemas(s, name) {
+				violations = v.locate(child, value[name], ptr+"/"+escapePointer(name), violations)
+			}
+		}
+	case []any:
+		for i, item := range value {
+			child := s.Items
+			if i < len(s.PrefixItems) {
+				child = s.PrefixItems[i]
+			}
+			if child != nil {
+				violations = v.locate(child, item, ptr+"/"+strconv.Itoa(i), violations)
+			}
+		}
+	}
+	if len(violations) > n {
+		return violations
+	}
+	return append(violations, newViolation(ptr, err))
+}
+
+// propertySchemas returns the schemas of s applying to the property
+// name. Forbidden properties are reported by the object itself.
+func (v *validator) propertySchemas(s *jsonschema.Schema, name string) []*jsonschema.Schema {
+	if prop, isDefined := s.Properties[name]; isDefined {
+		return []*jsonschema.Schema{prop}
+	}
+	schemas := make([]*jsonschema.Schema, 0, 1)
+	for expr, prop := range s.PatternProperties {
+		if v.patterns[expr].MatchString(name) {
+			schemas = append(schemas, prop)
+		}
+	}
+	if len(schemas) == 0 && s.AdditionalProperties != nil && !isFalseSchema(s.AdditionalProperties) {
+		schemas = append(schemas, s.AdditionalProperties)
+	}
+	return schemas
+}
+
+// newViolation returns the violation at ptr described by the validation error
+// err of jsonschema-go. Its message is prefixed by the locations of the
+// validated schemas and the violated keyword.
+func newViolation(ptr string, err error) Violation {
+	msg := err.Error()
+	for {
+		rest, isNested := strings.CutPrefix(msg, "validating ")
+		if !isNested {
+			break
+		}
+		_, msg, _ = strings.Cut(rest, ": ")
+	}
+	if strings.HasPrefix(msg, "unexpected additional properties") {
+		return Violation{Pointer: ptr, Keyword: "additionalProperties", Message: msg}
+	}
+	keyword, detail, hasKeyword := strings.Cut(msg, ": ")
+	if !hasKeyword {
+		return Violation{Pointer: ptr, Message: msg}
+	}
+	// e.g. dependentRequired["name"]
+	keyword, _, _ = strings.Cut(keyword, "[")
+	return Violation{Pointer: ptr, Keyword: keyword, Message: detail}
+}
+
+// inlineRefs replaces the references to the component schemas in s by copies
+// of the referenced schemas. Forbidden additional properties
+// are allowed unless strict. Recursive references are not supported.
+func inlineRefs(s *jsonschema.Schema, components map[string]*jsonschema.Schema, strict bool, seen []string) error {
+	if s.Ref != "" {
+		name, isComponent := strings.CutPrefix(s.Ref, schemaRefPrefix)
+		if !isComponent {
+			return fmt.Errorf("schema reference is not supported: %s", s.Ref)
+		}
+		if slices.Contains(seen, name) {
+			return fmt.Errorf("schema reference is recursive: %s", s.Ref)
+		}
+		ref, isDefined := components[name]
+		if !isDefined {
+			return fmt.Errorf("schema reference is not defined: %s", s.Ref)
+		}
+		inlined := ref.CloneSchemas()
+		if err := inlineRefs(inlined, components, strict, append(slices.Clip(seen), name)); err != nil {
+			return err
+		}
+		s.Ref = ""
+		if reflect.ValueOf(*s).IsZero() {
+			*s = *inlined
+			return nil
+		}
+		s.AllOf = append(s.AllOf, inlined)
+	}
+	if !strict && s.AdditionalProperties != nil && isFalseSchema(s.AdditionalProperties) {
+		s.AdditionalProperties = nil
+	}
+	children := slices.Concat(
+		slices.Collect(maps.Values(s.Properties)),
+		slices.Collect(maps.Values(s.PatternProperties)),
+		slices.Collect(maps.Values(s.DependentSchemas)),
+		s.PrefixItems, s.AllOf, s.AnyOf, s.OneOf,
+		[]*jsonschema.Schema{
+			s.Items, s.Contains, s.AdditionalProperties, s.PropertyNames,
+			s.Not, s.If, s.Then, s.Else,
+		},
+	)
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		if err := inlineRefs(child, components, strict, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isFalseSchema reports whether s is the schema false which does not allow
+// any value.
+func isFalseSchema(s *jsonschema.Schema) bool {
+	return s.Not != nil && reflect.ValueOf(*s.Not).IsZero()
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func escapePointer(token string) string {
+	return pointerEscaper.Replace(token)
+}